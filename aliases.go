@@ -0,0 +1,66 @@
+package leaderelection
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+)
+
+// SetCandidateAliases sets the other names this candidate may hold the lease under, e.g. the names of its previous
+// identity scheme during a rolling rename of candidate identities. A lease held under an alias counts as this
+// candidate's for IsLeader, and Renew carries it over to the candidate's own name, keeping its term, so migrating
+// identities doesn't cost the leader its leadership. Aliases must not name other live candidates. Calling it again
+// replaces the aliases; clear them with nil once the migration is over.
+func (e *Election) SetCandidateAliases(aliases []string) {
+	aliases = slices.DeleteFunc(slices.Clone(aliases), func(alias string) bool {
+		return alias == e.candidate
+	})
+	e.aliases.Store(&aliases)
+}
+
+// candidateAliases returns the aliases set by SetCandidateAliases.
+func (e *Election) candidateAliases() []string {
+	if aliases := e.aliases.Load(); aliases != nil {
+		return *aliases
+	}
+	return nil
+}
+
+// adoptAlias renews a lease held under one of the candidate's aliases under the candidate's own name, reporting
+// whether there was one.
+func (e *Election) adoptAlias(ctx context.Context) (bool, error) {
+	aliases := e.candidateAliases()
+	if len(aliases) == 0 {
+		return false, nil
+	}
+	lease := e.leaseParams()
+	sql := `UPDATE {records} SET leader_name = ?, ` + lease.renewal() + `
+			WHERE election_name=? and CAST(leader_name AS BINARY) IN ? and ` + lease.held()
+	result := e.conn(ctx).Exec(e.writeSQL(sql), e.candidate, e.storedName, aliases)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+	e.logEvent(ctx, slog.LevelInfo, "renewed a lease held under a candidate alias under the candidate's name",
+		OutcomeRenewed)
+	return true, nil
+}
+
+// aliasHeld reports whether one of the candidate's aliases holds a valid lease, remembering its term like IsLeader.
+func (e *Election) aliasHeld(ctx context.Context) (bool, error) {
+	aliases := e.candidateAliases()
+	if len(aliases) == 0 {
+		return false, nil
+	}
+	var term uint64
+	sql := `SELECT term FROM {records} where election_name=? and CAST(leader_name AS BINARY) IN ? and ` +
+		e.leaseParams().held()
+	result := e.conn(ctx).Raw(e.readSQL(sql), e.storedName, aliases).Scan(&term)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+	e.term.Store(term)
+	return true, nil
+}
@@ -0,0 +1,66 @@
+package leaderelection
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// BackoffStrategy decides how long the election loop waits before its next attempt to acquire leadership. attempt
+// counts the consecutive attempts that failed to acquire leadership, starting at 1, and lastOutcome says how the
+// latest one ended. Leaders are not subject to the strategy: they always renew every renew interval, as the lease
+// requires.
+type BackoffStrategy interface {
+	NextInterval(attempt int, lastOutcome Outcome) time.Duration
+}
+
+// BackoffFunc adapts an ordinary function to a BackoffStrategy.
+type BackoffFunc func(attempt int, lastOutcome Outcome) time.Duration
+
+func (f BackoffFunc) NextInterval(attempt int, lastOutcome Outcome) time.Duration {
+	return f(attempt, lastOutcome)
+}
+
+// ConstantBackoff waits interval between all attempts. ConstantBackoff(60 * time.Second) is the default strategy.
+func ConstantBackoff(interval time.Duration) BackoffStrategy {
+	return BackoffFunc(func(int, Outcome) time.Duration {
+		return interval
+	})
+}
+
+// ExponentialBackoff waits base after the first failed attempt, doubling with every further one up to max.
+func ExponentialBackoff(base time.Duration, max time.Duration) BackoffStrategy {
+	return BackoffFunc(func(attempt int, _ Outcome) time.Duration {
+		interval := base
+		for i := 1; i < attempt && interval < max; i++ {
+			interval *= 2
+		}
+		return min(interval, max)
+	})
+}
+
+// DecorrelatedJitterBackoff waits a random interval between base and three times the previous interval, capped at
+// max, which spreads out candidates that started contending at the same time. The strategy is stateful, so don't
+// share one between elections.
+func DecorrelatedJitterBackoff(base time.Duration, max time.Duration) BackoffStrategy {
+	return &decorrelatedJitter{base: base, max: max}
+}
+
+type decorrelatedJitter struct {
+	base time.Duration
+	max  time.Duration
+
+	mu   sync.Mutex
+	prev time.Duration
+}
+
+func (j *decorrelatedJitter) NextInterval(attempt int, _ Outcome) time.Duration {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if attempt <= 1 || j.prev < j.base {
+		j.prev = j.base
+	}
+	upper := 3 * j.prev
+	j.prev = min(j.base+rand.N(upper-j.base+1), j.max)
+	return j.prev
+}
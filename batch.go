@@ -0,0 +1,151 @@
+package leaderelection
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RenewBatch renews the leases the given elections of the Manager hold in one statement per lease setting, instead
+// of one per election. It reports, by election name, which renewals succeeded; an election whose lease was lost or has
+// expired, or whose candidate resigned, isn't renewed and reports false. Elections with candidate aliases are renewed
+// one by one instead, carrying a lease held under an alias over to the candidate's name like Election.Renew.
+func (m *Manager) RenewBatch(ctx context.Context, elections []*Election) (map[string]bool, error) {
+	ctx = orBackground(ctx)
+	ctx, cancel := context.WithTimeout(ctx, m.o.queryTimeout)
+	defer cancel()
+	byLease := make(map[LeaseParams][]*Election)
+	renewed := make(map[string]bool, len(elections))
+	for _, e := range elections {
+		done, err := e.participating()
+		if err != nil {
+			// resigned: its lease mustn't be taken back
+			renewed[e.name] = false
+			continue
+		}
+		defer done()
+		if len(e.candidateAliases()) > 0 {
+			ok, err := e.renew(ctx)
+			if err != nil {
+				return nil, ctxError(ctx, err)
+			}
+			renewed[e.name] = ok
+			continue
+		}
+		byLease[e.leaseParams()] = append(byLease[e.leaseParams()], e)
+	}
+	for lease, group := range byLease {
+		if err := m.renewGroup(ctx, lease, group, renewed); err != nil {
+			return nil, err
+		}
+	}
+	return renewed, nil
+}
+
+// renewGroup renews elections sharing lease settings, recording the results in renewed. Locking the rows that are
+// still held first tells which of them the update renews.
+func (m *Manager) renewGroup(ctx context.Context, lease LeaseParams, elections []*Election, renewed map[string]bool) error {
+	names := make(map[string]string, len(elections))
+	tuples := make([]string, 0, len(elections))
+	args := make([]interface{}, 0, 2*len(elections))
+	for _, e := range elections {
+		names[e.storedName] = e.name
+		renewed[e.name] = false
+		tuples = append(tuples, "(?, CAST(? AS BINARY))")
+		args = append(args, e.storedName, e.candidate)
+	}
+	// the elections of a Manager share their table names and hints
+	e := elections[0]
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var held []string
+		sql := `SELECT election_name FROM {records} WHERE (election_name, leader_name) IN (` + strings.Join(tuples, ", ") +
+			`) and ` + lease.held() + ` FOR UPDATE`
+		if err := tx.Raw(e.writeSQL(sql), args...).Scan(&held).Error; err != nil {
+			return err
+		}
+		if len(held) == 0 {
+			return nil
+		}
+		sql = `UPDATE {records} SET ` + lease.renewal() + ` WHERE election_name IN ?`
+		if err := tx.Exec(e.writeSQL(sql), held).Error; err != nil {
+			return err
+		}
+		for _, name := range held {
+			renewed[names[name]] = true
+		}
+		return nil
+	})
+}
+
+// renewBatch renews the leases held by the elections of Manager.RunAll together, every renew interval: leaders wait
+// in renew for the next batch instead of renewing on their own.
+type renewBatch struct {
+	m        *Manager
+	interval time.Duration
+	mu       sync.Mutex
+	pending  map[*Election]chan renewResult
+}
+
+type renewResult struct {
+	renewed bool
+	err     error
+}
+
+func newRenewBatch(m *Manager, interval time.Duration) *renewBatch {
+	return &renewBatch{m: m, interval: interval, pending: make(map[*Election]chan renewResult)}
+}
+
+// renew waits for the next batch to renew e's lease.
+func (b *renewBatch) renew(ctx context.Context, e *Election) (bool, error) {
+	ch := make(chan renewResult, 1)
+	b.mu.Lock()
+	b.pending[e] = ch
+	b.mu.Unlock()
+	select {
+	case <-ctx.Done():
+		b.mu.Lock()
+		delete(b.pending, e)
+		b.mu.Unlock()
+		return false, ctx.Err()
+	case result := <-ch:
+		return result.renewed, result.err
+	}
+}
+
+// run renews the pending leases every interval until ctx is done.
+func (b *renewBatch) run(ctx context.Context) {
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			b.flush(ctx)
+		}
+	}
+}
+
+func (b *renewBatch) flush(ctx context.Context) {
+	b.mu.Lock()
+	pending := b.pending
+	b.pending = make(map[*Election]chan renewResult)
+	b.mu.Unlock()
+	if len(pending) == 0 {
+		return
+	}
+	elections := make([]*Election, 0, len(pending))
+	for e := range pending {
+		elections = append(elections, e)
+	}
+	started := time.Now()
+	renewed, err := b.m.RenewBatch(ctx, elections)
+	latency := time.Since(started)
+	for e, ch := range pending {
+		e.opts.metrics.ObserveRenewLatency(e.name, latency)
+		ch <- renewResult{renewed: renewed[e.name], err: err}
+	}
+}
@@ -0,0 +1,44 @@
+package leaderelection
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// TestRenewBatchCarriesOverAliases checks that a lease held under a candidate alias is renewed by a batch, like by
+// Election.Renew, rather than reported lost because the batched predicate only matches the candidate's own name.
+func TestRenewBatchCarriesOverAliases(t *testing.T) {
+	db := &fakeDB{handle: func(query fakeQuery) (*fakeResult, error) {
+		switch {
+		case strings.Contains(query.sql, "FOR UPDATE"):
+			// the rows are held under the alias "old"
+			return &fakeResult{columns: []string{"election_name"}}, nil
+		case strings.HasPrefix(query.sql, "UPDATE election_records SET leader_name = ?"):
+			return &fakeResult{rowsAffected: 1}, nil
+		}
+		return nil, nil
+	}}
+	m := newFakeManager(t, db)
+	aliased, err := m.NewElection("aliased")
+	if err != nil {
+		t.Fatalf("NewElection: %v", err)
+	}
+	aliased.SetCandidateAliases([]string{"old"})
+	plain, err := m.NewElection("plain")
+	if err != nil {
+		t.Fatalf("NewElection: %v", err)
+	}
+	renewed, err := m.RenewBatch(context.Background(), []*Election{aliased, plain})
+	if err != nil {
+		t.Fatalf("RenewBatch() = %v", err)
+	}
+	if !renewed["aliased"] || renewed["plain"] {
+		t.Fatalf("RenewBatch() = %v, want the aliased election renewed and the other one not", renewed)
+	}
+	for _, query := range db.received() {
+		if strings.Contains(query.sql, "FOR UPDATE") && len(query.args) != 2 {
+			t.Fatalf("the batch locked rows with %d arguments, want the plain election's 2:\n%s", len(query.args), query.sql)
+		}
+	}
+}
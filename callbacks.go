@@ -0,0 +1,106 @@
+package leaderelection
+
+import (
+	"context"
+	"sync"
+)
+
+// CallbackExecutor runs fn asynchronously, e.g. on an application's worker pool or errgroup, giving it control over
+// the goroutines leadership callbacks run on and how their panics are handled. fn must eventually run, and must not
+// be run on the calling goroutine. See WithCallbackExecutor.
+type CallbackExecutor func(fn func())
+
+// goExecutor is the default CallbackExecutor, running each fn on a new goroutine.
+func goExecutor(fn func()) {
+	go fn()
+}
+
+// callbackQueue delivers leadership callbacks on a dedicated goroutine so a slow callback never delays renewals.
+//
+// The election loop only records the state it wants the application to be in; the goroutine delivers a callback
+// whenever that differs from the state it last delivered. Callbacks therefore always alternate become, lose, become,
+// ... and never run concurrently, while transitions that happen while a callback is still running are coalesced to
+// the latest state: losing and regaining leadership during a slow become callback delivers nothing further.
+type callbackQueue struct {
+	ctx    context.Context
+	become ContextCallbackFunc
+	lose   func(context.Context, LossReason)
+
+	mu      sync.Mutex
+	leading bool
+	reason  LossReason
+	signal  chan struct{}
+	done    chan struct{}
+}
+
+// newCallbackQueue delivers callbacks with ctx's values, but not its cancellation: the final lose callback is delivered
+// after ctx is done. The delivering goroutine is started through exec, and runs for as long as the queue.
+func newCallbackQueue(ctx context.Context, exec CallbackExecutor, become ContextCallbackFunc, lose func(context.Context, LossReason)) *callbackQueue {
+	q := &callbackQueue{
+		ctx:    context.WithoutCancel(ctx),
+		become: become,
+		lose:   lose,
+		signal: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	exec(q.run)
+	return q
+}
+
+// set records whether the application should consider itself the leader, and if not why, without waiting for the
+// callback.
+func (q *callbackQueue) set(leading bool, reason LossReason) {
+	q.mu.Lock()
+	q.leading = leading
+	q.reason = reason
+	q.mu.Unlock()
+	select {
+	case q.signal <- struct{}{}:
+	default:
+	}
+}
+
+// close waits for the pending callbacks to be delivered and stops the queue.
+func (q *callbackQueue) close() {
+	close(q.signal)
+	<-q.done
+}
+
+func (q *callbackQueue) run() {
+	defer close(q.done)
+	delivered := false
+	for range q.signal {
+		for {
+			q.mu.Lock()
+			leading, reason := q.leading, q.reason
+			q.mu.Unlock()
+			if leading == delivered {
+				break
+			}
+			if leading {
+				q.become(q.ctx)
+			} else {
+				q.lose(q.ctx, reason)
+			}
+			delivered = leading
+		}
+	}
+}
+
+// LossReason explains why a leader stopped leading.
+type LossReason string
+
+const (
+	// LossReasonLeaseLost means a campaign found the lease held by another candidate.
+	LossReasonLeaseLost LossReason = "lease_lost"
+	// LossReasonRenewFailures means the leader stepped down after too many consecutive renewal errors.
+	LossReasonRenewFailures LossReason = "renew_failures"
+	// LossReasonSuperseded means the leader found its lease under a term other than the one it acquired, i.e. the
+	// lease lapsed and was taken over at some point, and whoever led in between may have acted as leader too.
+	LossReasonSuperseded LossReason = "superseded"
+	// LossReasonStopped means the election stopped while leading.
+	LossReasonStopped LossReason = "stopped"
+	// LossReasonBackendChanged means the leader renewed its lease on another database server than it acquired it on,
+	// as detected by WithBackendBinding.
+	LossReasonBackendChanged LossReason = "backend_changed"
+)
@@ -0,0 +1,65 @@
+package leaderelection
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// CandidateEntry is the heartbeat of a candidate participating in an election, kept by candidates running
+// WithCandidateRegistration.
+type CandidateEntry struct {
+	ID           uint      `gorm:"primaryKey"`
+	ElectionName string    `gorm:"uniqueIndex:uidx_election_candidate"`
+	Candidate    string    `gorm:"uniqueIndex:uidx_election_candidate"`
+	LastSeen     time.Time `gorm:"index"`
+}
+
+func (CandidateEntry) TableName() string {
+	return "election_candidates"
+}
+
+// Candidates returns the candidates currently participating in the election, leader included: those whose heartbeat
+// is more recent than the lease duration. Only candidates running WithCandidateRegistration are listed.
+func (e *Election) Candidates(ctx context.Context) ([]string, error) {
+	ctx = orBackground(ctx)
+	if err := e.checkOpen(); err != nil {
+		return nil, err
+	}
+	if e.memory != nil {
+		return nil, errMemoryUnsupported("Candidates")
+	}
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	var candidates []string
+	lease := e.leaseParams()
+	sql := `SELECT candidate FROM {candidates} WHERE election_name=? and last_seen >= ` + lease.now() + ` - ` +
+		lease.interval(lease.Seconds, lease.LeaseMicros) + ` ORDER BY candidate`
+	if err := e.conn(ctx).Raw(e.readSQL(sql), e.storedName).Scan(&candidates).Error; err != nil {
+		return nil, ctxError(ctx, err)
+	}
+	return candidates, nil
+}
+
+// heartbeat records that this candidate is participating in the election. Failures are only logged: the heartbeat
+// is informational and must not hold up the election.
+func (e *Election) heartbeat(ctx context.Context) {
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	now := e.leaseParams().now()
+	sql := `INSERT INTO {candidates} (election_name, candidate, last_seen) VALUES (?, ?, ` + now + `)
+			ON DUPLICATE KEY UPDATE last_seen = ` + now
+	if err := e.conn(ctx).Exec(e.writeSQL(sql), e.storedName, e.candidate).Error; err != nil {
+		e.logEvent(ctx, slog.LevelWarn, "failed to record candidate heartbeat", OutcomeError, slog.Any("error", err))
+	}
+}
+
+// deregister removes this candidate's heartbeat once it stops participating, so it drops off Candidates straight away.
+func (e *Election) deregister(ctx context.Context) {
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	sql := `DELETE FROM {candidates} WHERE election_name=? and candidate=CAST(? AS BINARY)`
+	if err := e.conn(ctx).Exec(e.writeSQL(sql), e.storedName, e.candidate).Error; err != nil {
+		e.logEvent(ctx, slog.LevelWarn, "failed to deregister candidate", OutcomeError, slog.Any("error", err))
+	}
+}
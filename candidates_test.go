@@ -0,0 +1,22 @@
+package leaderelection
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestCandidatesReportsContextErrors checks that a query aborted by the context, which the driver may report as a
+// lost connection, surfaces the context error.
+func TestCandidatesReportsContextErrors(t *testing.T) {
+	e := newFakeElection(t, &fakeDB{handle: func(query fakeQuery) (*fakeResult, error) {
+		<-query.ctx.Done()
+		return nil, errKilledConn
+	}})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := e.Candidates(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Candidates() = %v, want context.DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,137 @@
+package leaderelection
+
+import (
+	"time"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+)
+
+// ResolvedConfig is the configuration an election actually runs with, after defaults and derived values have been
+// applied. It holds no secrets, so it can be logged.
+type ResolvedConfig struct {
+	ElectionName string
+	// StoredName is the name the election is stored under, see StoredElectionName.
+	StoredName string
+	Candidate  string
+	// Backend is "mysql", or "memory" for elections created with NewMemoryElection.
+	Backend string
+	// DSN is the data source name the election connects with, with the password redacted. It is empty for in-memory
+	// elections.
+	DSN string
+	// RecordsTable, HistoryTable, CandidatesTable and StatsTable are the resolved table names, qualified with the
+	// schema of WithSchema, if any.
+	RecordsTable    string
+	HistoryTable    string
+	CandidatesTable string
+	StatsTable      string
+
+	LeaseDuration    time.Duration
+	RenewInterval    time.Duration
+	QueryTimeout     time.Duration
+	AcquireTimeout   time.Duration
+	MaxRenewFailures int
+	SafetyFactor     float64
+	MinHoldDuration  time.Duration
+	SkewTolerance    time.Duration
+	ExpiryJitter     time.Duration
+	ShutdownGrace    time.Duration
+	PreferredLeader  string
+	YieldGrace       time.Duration
+	Zone             string
+	PreferredZone    string
+	ZoneGrace        time.Duration
+	FairnessDelay    time.Duration
+	StealBackoff     time.Duration
+	PostLossCooldown time.Duration
+	// ConcurrentCampaigns is the WithMaxConcurrentCampaigns limit of the elections run by Manager.RunAll.
+	ConcurrentCampaigns int
+	// LongLeadership is the WithOnLongLeadership threshold, zero when disabled.
+	LongLeadership time.Duration
+	// FallbackLockPath and FallbackAfter are the file lock fallback, see WithFileLockFallback.
+	FallbackLockPath string
+	FallbackAfter    time.Duration
+
+	AutoMigrate           bool
+	CreateOnlyMigrate     bool
+	History               bool
+	Stats                 bool
+	CandidateRegistration bool
+	DedicatedConn         bool
+	CombinedVerify        bool
+	LightweightRenewal    bool
+	MicrosecondPrecision  bool
+	EpochLease            bool
+	ExplicitOwnership     bool
+	BackendBinding        bool
+	// MultiStatements reports whether WithCombinedVerify can send its statements together, see WithCombinedVerify.
+	MultiStatements bool
+}
+
+// Config returns the configuration the election resolved to, e.g. to log it at startup or to find out why an
+// election behaves the way it does.
+func (e *Election) Config() ResolvedConfig {
+	o := e.opts
+	config := ResolvedConfig{
+		ElectionName:          e.name,
+		StoredName:            e.storedName,
+		Candidate:             e.candidate,
+		Backend:               "mysql",
+		DSN:                   e.redactedDSN,
+		LeaseDuration:         o.leaseDuration,
+		RenewInterval:         o.renewInterval,
+		QueryTimeout:          o.queryTimeout,
+		AcquireTimeout:        o.acquireTimeout,
+		MaxRenewFailures:      o.maxRenewFailures,
+		ConcurrentCampaigns:   o.maxCampaigns,
+		SafetyFactor:          o.safetyFactor,
+		MinHoldDuration:       o.minHold,
+		SkewTolerance:         o.skewTolerance,
+		ExpiryJitter:          o.expiryJitter,
+		ShutdownGrace:         o.shutdownGrace,
+		PreferredLeader:       o.preferredLeader,
+		YieldGrace:            o.yieldGrace,
+		Zone:                  o.zone,
+		PreferredZone:         o.preferredZone,
+		ZoneGrace:             o.zoneGrace,
+		FairnessDelay:         o.fairnessDelay,
+		StealBackoff:          o.stealBackoff,
+		PostLossCooldown:      o.postLossCooldown,
+		LongLeadership:        o.longLeadership,
+		FallbackLockPath:      o.fallbackPath,
+		FallbackAfter:         o.fallbackAfter,
+		AutoMigrate:           o.autoMigrate,
+		CreateOnlyMigrate:     o.createOnlyMigrate,
+		History:               o.history,
+		Stats:                 o.stats,
+		CandidateRegistration: o.registerCandidate,
+		DedicatedConn:         o.dedicatedConn,
+		CombinedVerify:        o.combinedVerify,
+		LightweightRenewal:    o.lightRenewal,
+		MicrosecondPrecision:  o.microseconds,
+		EpochLease:            o.epochLease,
+		ExplicitOwnership:     !o.rowsAffectedFast,
+		BackendBinding:        o.bindBackend,
+		MultiStatements:       e.multiStatements,
+	}
+	if e.memory != nil {
+		config.Backend = "memory"
+		return config
+	}
+	config.RecordsTable = e.sql("{records}")
+	config.HistoryTable = e.sql("{history}")
+	config.CandidatesTable = e.sql("{candidates}")
+	config.StatsTable = e.sql("{stats}")
+	return config
+}
+
+// redactDSN returns dsn with its password masked, or nothing if dsn can't be parsed, as it might then expose it.
+func redactDSN(dsn string) string {
+	cfg, err := mysqldriver.ParseDSN(dsn)
+	if err != nil {
+		return ""
+	}
+	if cfg.Passwd != "" {
+		cfg.Passwd = "REDACTED"
+	}
+	return cfg.FormatDSN()
+}
@@ -0,0 +1,102 @@
+package leaderelection
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+)
+
+var (
+	// ErrNotConnected is wrapped by errors caused by failing to reach or query the election database.
+	ErrNotConnected = errors.New("leaderelection: not connected to the election database")
+	// ErrInvalidConfig is wrapped by errors caused by invalid election options or configuration.
+	ErrInvalidConfig = errors.New("leaderelection: invalid configuration")
+	// ErrNoLeader is returned when an election has no candidate holding a valid lease.
+	ErrNoLeader = errors.New("leaderelection: election has no leader")
+	// ErrLeaseLost is returned when this candidate doesn't (or no longer) hold a valid lease on the election.
+	ErrLeaseLost = errors.New("leaderelection: lease lost")
+	// ErrNotLeader is returned when an operation requires this candidate to be the leader, and it isn't.
+	ErrNotLeader = errors.New("leaderelection: not the leader")
+	// ErrLeaseHeld is returned when another candidate holds a valid lease on the election.
+	ErrLeaseHeld = errors.New("leaderelection: lease held by another candidate")
+	// ErrResigned is returned when campaigning or renewing on behalf of a candidate that resigned, see Election.Resign.
+	ErrResigned = errors.New("leaderelection: candidate resigned")
+	// ErrElectionClosed is returned by the methods of an election that was closed, see Election.Close.
+	ErrElectionClosed = errors.New("leaderelection: election closed")
+	// ErrTableMissing is wrapped by errors caused by the election table not existing and not being recreated.
+	ErrTableMissing = errors.New("leaderelection: election table is missing")
+)
+
+// MySQL server error numbers the election reacts to.
+const (
+	errTooManyConnections = 1040
+	errTableExists        = 1050
+	errServerShutdown     = 1053
+	errDuplicateColumn    = 1060
+	errDuplicateKeyName   = 1061
+	errNoSuchTable        = 1146
+	errLockWaitTimeout    = 1205
+	errDeadlock           = 1213
+	errReadOnly           = 1290
+	errConnectionKilled   = 1927
+	// client errors, as relayed by some proxies
+	errServerGone = 2006
+	errServerLost = 2013
+)
+
+// alreadyExists reports whether err is MySQL refusing to create a table, column or index that exists, as when
+// candidates starting together race to migrate the same schema.
+func alreadyExists(err error) bool {
+	var mysqlErr *mysqldriver.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return false
+	}
+	switch mysqlErr.Number {
+	case errTableExists, errDuplicateColumn, errDuplicateKeyName:
+		return true
+	}
+	return false
+}
+
+// IsRetryable is the default classifier RunElection uses to decide whether a failed campaign or renewal is worth
+// retrying. It treats as transient:
+//   - deadlocks (1213) and lock wait timeouts (1205),
+//   - too many connections (1040), server shutdown (1053) and a server running read-only (1290), as seen during
+//     restarts and failovers,
+//   - connection errors: broken, closed or killed connections (1927, and 2006 and 2013 as relayed by proxies), network
+//     errors and unexpected EOFs,
+//   - a query that exceeded the query timeout.
+//
+// Anything else, such as a syntax or permission error, is not going to resolve itself and stops the election.
+func IsRetryable(err error) bool {
+	var mysqlErr *mysqldriver.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case errDeadlock, errLockWaitTimeout, errTooManyConnections, errServerShutdown, errReadOnly:
+			return true
+		}
+		return connectionLost(err)
+	}
+	var netErr net.Error
+	return connectionLost(err) || errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// connectionLost reports whether err means the connection it was sent on is gone, e.g. killed by a DBA or dropped by
+// a server restart, so it has to be replaced rather than used again.
+func connectionLost(err error) bool {
+	var mysqlErr *mysqldriver.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case errConnectionKilled, errServerGone, errServerLost:
+			return true
+		}
+		return false
+	}
+	return errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysqldriver.ErrInvalidConn) ||
+		errors.Is(err, sql.ErrConnDone) || errors.Is(err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,39 @@
+package leaderelection
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+)
+
+func TestConnectionLost(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want bool
+	}{
+		{&mysqldriver.MySQLError{Number: errConnectionKilled}, true},
+		{&mysqldriver.MySQLError{Number: errServerGone}, true},
+		{&mysqldriver.MySQLError{Number: errServerLost}, true},
+		{fmt.Errorf("campaign failed: %w", &mysqldriver.MySQLError{Number: errServerLost}), true},
+		{driver.ErrBadConn, true},
+		{mysqldriver.ErrInvalidConn, true},
+		{sql.ErrConnDone, true},
+		{io.ErrUnexpectedEOF, true},
+		{&mysqldriver.MySQLError{Number: errDeadlock}, false},
+		{context.DeadlineExceeded, false},
+		{errors.New("syntax error"), false},
+	} {
+		if got := connectionLost(tc.err); got != tc.want {
+			t.Errorf("connectionLost(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+		if tc.want && !IsRetryable(tc.err) {
+			t.Errorf("IsRetryable(%v) = false, want a lost connection retried", tc.err)
+		}
+	}
+}
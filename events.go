@@ -0,0 +1,101 @@
+package leaderelection
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// LeadershipEvent describes a leadership transition of the candidate running an election.
+type LeadershipEvent struct {
+	ElectionName string
+	Candidate    string
+	// Leading is true once the candidate won the election, and false once it stopped leading.
+	Leading bool
+	// Term is the term won, or the term last held when losing leadership.
+	Term uint64
+	// Reason explains why leadership was lost; it is empty when Leading.
+	Reason LossReason
+	Time   time.Time
+}
+
+// subscriberBuffer is how many undelivered events a subscriber can fall behind by before its oldest ones are dropped.
+const subscriberBuffer = 16
+
+// Subscribe streams the leadership events of e's Run loop until ctx is done, when the channel is closed. The first
+// event is the current state: the latest transition, or a not-leading event if there hasn't been one yet, so late
+// subscribers such as dashboards don't have to wait for the next change. Each subscriber has its own buffer; one that
+// falls behind loses its oldest events rather than holding up the election or other subscribers.
+func (e *Election) Subscribe(ctx context.Context) <-chan LeadershipEvent {
+	ctx = orBackground(ctx)
+	ch := make(chan LeadershipEvent, subscriberBuffer)
+	b := &e.events
+	b.mu.Lock()
+	if b.subscribers == nil {
+		b.subscribers = make(map[chan LeadershipEvent]struct{})
+	}
+	b.subscribers[ch] = struct{}{}
+	latest := b.latest
+	if latest == nil {
+		latest = &LeadershipEvent{ElectionName: e.name, Candidate: e.candidate, Time: time.Now()}
+	}
+	ch <- *latest
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		delete(b.subscribers, ch)
+		close(ch)
+		b.mu.Unlock()
+	}()
+	return ch
+}
+
+func (e *Election) newEvent(leading bool, reason LossReason) LeadershipEvent {
+	return LeadershipEvent{
+		ElectionName: e.name,
+		Candidate:    e.candidate,
+		Leading:      leading,
+		Term:         e.Term(),
+		Reason:       reason,
+		Time:         time.Now(),
+	}
+}
+
+// transition announces a leadership transition to subscribers and to the WithOnTransition hook.
+func (e *Election) transition(event LeadershipEvent) {
+	e.events.publish(event)
+	e.counters.transitions.Add(1)
+	if e.opts.onTransition != nil {
+		e.opts.onTransition(event)
+	}
+}
+
+// broadcaster fans leadership events out to subscribers, remembering the latest one for new subscribers.
+type broadcaster struct {
+	mu          sync.Mutex
+	latest      *LeadershipEvent
+	subscribers map[chan LeadershipEvent]struct{}
+}
+
+func (b *broadcaster) publish(event LeadershipEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.latest = &event
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			// the subscriber is behind: make room by dropping its oldest event
+			select {
+			case <-ch:
+			default:
+			}
+			select {
+			case ch <- event:
+			default:
+			}
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package leaderelection
+
+import (
+	"context"
+	"time"
+)
+
+// AcquireReason explains why a campaign would or wouldn't acquire the lease, see ExplainAcquire.
+type AcquireReason string
+
+const (
+	// AcquireReasonNoElection means the election has no row yet, so the campaign would create it.
+	AcquireReasonNoElection AcquireReason = "no_election"
+	// AcquireReasonHeldBySelf means this candidate holds the lease, so the campaign would renew it.
+	AcquireReasonHeldBySelf AcquireReason = "held_by_self"
+	// AcquireReasonExpired means the lease of another candidate expired, so the campaign would take it over.
+	AcquireReasonExpired AcquireReason = "expired"
+	// AcquireReasonHeldByOther means another candidate holds a valid lease.
+	AcquireReasonHeldByOther AcquireReason = "held_by_other"
+	// AcquireReasonMinHold means the lease of another candidate expired, but the minimum hold period of its
+	// acquisition isn't over (see WithMinHoldDuration).
+	AcquireReasonMinHold AcquireReason = "min_hold"
+)
+
+// AcquireExplanation is the outcome the next campaign would have, as reported by ExplainAcquire.
+type AcquireExplanation struct {
+	WouldAcquire bool          `json:"would_acquire"`
+	Reason       AcquireReason `json:"reason"`
+	// Leader and Term are the current holder of the row and its term, whether or not its lease is valid. They are
+	// empty for AcquireReasonNoElection.
+	Leader string `json:"leader,omitempty"`
+	Term   uint64 `json:"term,omitempty"`
+	// UntilStealable is how long until another candidate's lease can be taken over, computed on the database server.
+	// It is zero when the campaign would acquire the lease.
+	UntilStealable time.Duration `json:"until_stealable"`
+}
+
+// ExplainAcquire reads the election row and reports, without modifying anything, whether this candidate's next
+// campaign would acquire the lease and why, with the time until a lease held by another candidate can be taken over.
+// It evaluates the same conditions as the acquisition upsert of MySQLBuilder, on the server clock, including the clock
+// skew tolerance, expiry jitter and minimum hold period; delays the election loop adds before campaigning, such as
+// WithFairness or WithStealBackoff, are not included. It is a diagnostic, and the answer can be stale by the time it
+// is returned.
+func (e *Election) ExplainAcquire(ctx context.Context) (AcquireExplanation, error) {
+	ctx = orBackground(ctx)
+	if err := e.checkOpen(); err != nil {
+		return AcquireExplanation{}, err
+	}
+	if e.memory != nil {
+		return AcquireExplanation{}, errMemoryUnsupported("ExplainAcquire")
+	}
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	lease := e.leaseParams()
+	now := lease.now()
+	var row struct {
+		LeaderName string
+		Term       uint64
+		Expired    bool
+		HoldOver   bool
+		WaitMicros int64
+	}
+	sql := `SELECT leader_name, term,
+			NOT (` + lease.heldFor(lease.Seconds+lease.SkewSeconds, lease.LeaseMicros+lease.SkewMicros) + `) AS expired,
+			(hold_until IS NULL or hold_until <= ` + now + `) AS hold_over,
+			GREATEST(` + lease.untilExpiry(lease.SkewSeconds, lease.SkewMicros) + `,
+				COALESCE(TIMESTAMPDIFF(MICROSECOND, ` + now + `, hold_until), 0)) AS wait_micros
+			FROM {records} where election_name=?`
+	result := e.conn(ctx).Raw(e.writeSQL(sql), e.storedName).Scan(&row)
+	if result.Error != nil {
+		return AcquireExplanation{}, ctxError(ctx, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return AcquireExplanation{WouldAcquire: true, Reason: AcquireReasonNoElection}, nil
+	}
+	explanation := AcquireExplanation{Leader: row.LeaderName, Term: row.Term}
+	switch {
+	case row.LeaderName == e.candidate:
+		explanation.WouldAcquire, explanation.Reason = true, AcquireReasonHeldBySelf
+	case row.Expired && row.HoldOver:
+		explanation.WouldAcquire, explanation.Reason = true, AcquireReasonExpired
+	case row.Expired:
+		explanation.Reason = AcquireReasonMinHold
+	default:
+		explanation.Reason = AcquireReasonHeldByOther
+	}
+	if !explanation.WouldAcquire && row.WaitMicros > 0 {
+		explanation.UntilStealable = time.Duration(row.WaitMicros) * time.Microsecond
+	}
+	return explanation, nil
+}
@@ -0,0 +1,284 @@
+package leaderelection
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// fakeDB is a database/sql driver answering queries from a handler, so the MySQL paths of elections can be tested
+// without a server. Each connection it opens gets the next id, starting at 1.
+type fakeDB struct {
+	handle func(query fakeQuery) (*fakeResult, error)
+
+	mu      sync.Mutex
+	conns   int
+	queries []fakeQuery
+	killed  map[int]bool
+}
+
+// fakeQuery is a statement a connection of a fakeDB received.
+type fakeQuery struct {
+	ctx  context.Context
+	conn int
+	sql  string
+	args []driver.NamedValue
+}
+
+// hasDeadline reports whether the statement was sent with a deadline, such as the query timeout.
+func (q fakeQuery) hasDeadline() bool {
+	_, ok := q.ctx.Deadline()
+	return ok
+}
+
+// fakeResult is the answer to a statement: rows for a query, or the affected row count for an exec.
+type fakeResult struct {
+	columns      []string
+	rows         [][]driver.Value
+	rowsAffected int64
+}
+
+// quietLogs keeps the logs of elections out of the test output.
+var quietLogs = WithLogger(slog.New(slog.DiscardHandler))
+
+// newFakeElection creates an election querying db, without migrating its tables.
+func newFakeElection(t *testing.T, db *fakeDB, opts ...Option) *Election {
+	t.Helper()
+	return newFakeCandidate(t, db, "candidate", opts...)
+}
+
+// newFakeCandidate is newFakeElection for another candidate than "candidate".
+func newFakeCandidate(t *testing.T, db *fakeDB, candidate string, opts ...Option) *Election {
+	t.Helper()
+	e, err := newElection("test", candidate, append([]Option{quietLogs}, opts...))
+	if err != nil {
+		t.Fatalf("newElection: %v", err)
+	}
+	if e.db, err = db.open(); err != nil {
+		t.Fatalf("gorm.Open: %v", err)
+	}
+	e.ownsDB = true
+	if err = e.resolveTables(); err != nil {
+		t.Fatalf("resolveTables: %v", err)
+	}
+	t.Cleanup(func() { _ = e.Close() })
+	return e
+}
+
+func (db *fakeDB) open() (*gorm.DB, error) {
+	return gorm.Open(mysql.New(mysql.Config{
+		Conn:                      sql.OpenDB(db),
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DisableAutomaticPing: true, Logger: logger.Discard})
+}
+
+// kill makes the connection fail every statement like a connection killed on the server.
+func (db *fakeDB) kill(conn int) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.killed == nil {
+		db.killed = make(map[int]bool)
+	}
+	db.killed[conn] = true
+}
+
+// received returns the statements received so far.
+func (db *fakeDB) received() []fakeQuery {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return append([]fakeQuery(nil), db.queries...)
+}
+
+func (db *fakeDB) Connect(context.Context) (driver.Conn, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.conns++
+	return &fakeConn{db: db, id: db.conns}, nil
+}
+
+func (db *fakeDB) Driver() driver.Driver {
+	return fakeDriver{}
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("fakeDB connections are opened through its connector")
+}
+
+type fakeConn struct {
+	db *fakeDB
+	id int
+}
+
+var (
+	_ driver.ExecerContext  = (*fakeConn)(nil)
+	_ driver.QueryerContext = (*fakeConn)(nil)
+	_ driver.ConnBeginTx    = (*fakeConn)(nil)
+	_ driver.Validator      = (*fakeConn)(nil)
+)
+
+func (c *fakeConn) run(ctx context.Context, query string, args []driver.NamedValue) (*fakeResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.db.mu.Lock()
+	if c.db.killed[c.id] {
+		c.db.mu.Unlock()
+		return nil, errKilledConn
+	}
+	q := fakeQuery{ctx: ctx, conn: c.id, sql: query, args: args}
+	c.db.queries = append(c.db.queries, q)
+	c.db.mu.Unlock()
+	if c.db.handle == nil {
+		return &fakeResult{}, nil
+	}
+	result, err := c.db.handle(q)
+	if result == nil && err == nil {
+		result = &fakeResult{}
+	}
+	return result, err
+}
+
+// errKilledConn is what MySQL reports for a connection killed from under a query.
+var errKilledConn = &mysqldriver.MySQLError{
+	Number:  errServerLost,
+	Message: "Lost connection to MySQL server during query",
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	result, err := c.run(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(result.rowsAffected), nil
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	result, err := c.run(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{result: result}, nil
+}
+
+func (c *fakeConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	return fakeTx{}, nil
+}
+
+func (c *fakeConn) IsValid() bool {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	return !c.db.killed[c.id]
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("fakeDB doesn't prepare statements")
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return fakeTx{}, nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeRows struct {
+	result *fakeResult
+	next   int
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.result.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.result.rows) {
+		return io.EOF
+	}
+	copy(dest, r.result.rows[r.next])
+	r.next++
+	return nil
+}
+
+// row is a result of one row.
+func row(columns []string, values ...driver.Value) *fakeResult {
+	return &fakeResult{columns: columns, rows: [][]driver.Value{values}}
+}
+
+// fakeLease is the row of one election, answering the campaigns, renewals and checks of its candidates consistently,
+// for candidates sharing a fakeDB. Like under MySQL's default case-insensitive collation, candidate names only differ
+// by case where a statement compares them as binary strings. The lease never expires, but resigning gives it up.
+type fakeLease struct {
+	mu       sync.Mutex
+	leader   string
+	term     int64
+	resigned bool
+}
+
+func (l *fakeLease) handle(query fakeQuery) (*fakeResult, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	binary := strings.Contains(query.sql, "AS BINARY)")
+	leads := func(candidate driver.Value) bool {
+		if binary {
+			return l.leader == fmt.Sprint(candidate)
+		}
+		return strings.EqualFold(l.leader, fmt.Sprint(candidate))
+	}
+	held := l.leader != "" && !l.resigned
+	switch {
+	case strings.HasPrefix(query.sql, "INSERT INTO election_records"):
+		candidate := query.args[1].Value
+		switch {
+		case l.leader == "":
+			l.leader, l.term = fmt.Sprint(candidate), 1
+			return &fakeResult{rowsAffected: 1}, nil
+		case held && leads(candidate):
+			return &fakeResult{rowsAffected: 2}, nil
+		case held:
+			return &fakeResult{rowsAffected: 0}, nil
+		}
+		l.leader, l.term, l.resigned = fmt.Sprint(candidate), l.term+1, false
+		return &fakeResult{rowsAffected: 2}, nil
+	case strings.HasPrefix(query.sql, "SELECT leader_name, "):
+		return row([]string{"leader_name", "term", "held"}, l.leader, l.term, held), nil
+	case strings.HasPrefix(query.sql, "SELECT term FROM"):
+		if held && leads(query.args[1].Value) {
+			return row([]string{"term"}, l.term), nil
+		}
+		return &fakeResult{columns: []string{"term"}}, nil
+	case strings.HasPrefix(query.sql, "UPDATE election_records SET"):
+		if !held || !leads(query.args[1].Value) ||
+			strings.Contains(query.sql, "term=?") && fmt.Sprint(query.args[2].Value) != fmt.Sprint(l.term) {
+			return &fakeResult{rowsAffected: 0}, nil
+		}
+		if strings.HasPrefix(query.sql, "UPDATE election_records SET last_update = NOW() - ") {
+			l.resigned = true
+		}
+		return &fakeResult{rowsAffected: 1}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,57 @@
+package leaderelection
+
+import (
+	"os"
+	"time"
+)
+
+// fallbackLock is the file lock a candidate leads by while the database is unreachable, see WithFileLockFallback.
+type fallbackLock struct {
+	path  string
+	after time.Duration
+	// since is when the database became unreachable, or zero while it is reachable.
+	since time.Time
+	file  *os.File
+}
+
+func (e *Election) newFallbackLock() *fallbackLock {
+	return &fallbackLock{path: e.opts.fallbackPath, after: e.opts.fallbackAfter}
+}
+
+// failed records that the database couldn't be queried.
+func (l *fallbackLock) failed() {
+	if l.since.IsZero() {
+		l.since = time.Now()
+	}
+}
+
+// reached records that the database could be queried.
+func (l *fallbackLock) reached() {
+	l.since = time.Time{}
+}
+
+// due reports whether the database has been unreachable for long enough to try leading by the file lock.
+func (l *fallbackLock) due() bool {
+	return l.path != "" && l.file == nil && !l.since.IsZero() && time.Since(l.since) >= l.after
+}
+
+func (l *fallbackLock) held() bool {
+	return l.file != nil
+}
+
+// acquire takes the file lock without waiting for it.
+func (l *fallbackLock) acquire() error {
+	file, err := lockFile(l.path)
+	if err != nil {
+		return err
+	}
+	l.file = file
+	return nil
+}
+
+func (l *fallbackLock) release() {
+	if l.file != nil {
+		unlockFile(l.file)
+		l.file = nil
+	}
+}
@@ -0,0 +1,17 @@
+//go:build !unix
+
+package leaderelection
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+func lockFile(string) (*os.File, error) {
+	return nil, fmt.Errorf("file lock fallback is only supported on unix: %w", errors.ErrUnsupported)
+}
+
+func unlockFile(file *os.File) {
+	file.Close()
+}
@@ -0,0 +1,88 @@
+//go:build unix
+
+package leaderelection
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestLeavingDegradedModeAdmitsTerm checks that a candidate leading by the file lock fences the term the database
+// grants it once reachable again, and steps down if the fence rejects it.
+func TestLeavingDegradedModeAdmitsTerm(t *testing.T) {
+	for name, fenceErr := range map[string]error{
+		"admitted": nil,
+		"rejected": errors.New("fenced out"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			var down atomic.Bool
+			down.Store(true)
+			holder := leaseHolder("candidate")
+			db := &fakeDB{handle: func(query fakeQuery) (*fakeResult, error) {
+				if down.Load() {
+					return nil, errKilledConn
+				}
+				return holder(query)
+			}}
+			fenced := make(chan uint64, 1)
+			e := newFakeElection(t, db, append(slices.Clone(shortLease), WithBackoffStrategy(ConstantBackoff(5*time.Millisecond)),
+				WithFileLockFallback(filepath.Join(t.TempDir(), "lock"), time.Millisecond),
+				WithCorrelationID(func(context.Context) string { return "deploy-1" }),
+				WithFence(func(_ context.Context, term uint64) error {
+					select {
+					case fenced <- term:
+					default:
+					}
+					return fenceErr
+				}))...)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			elected := make(chan struct{}, 1)
+			var lost atomic.Int32
+			stopped := make(chan error, 1)
+			go func() {
+				stopped <- e.Run(ctx, func() { elected <- struct{}{} }, func() { lost.Add(1) })
+			}()
+			select {
+			case <-elected:
+			case <-time.After(time.Second):
+				t.Fatal("the candidate didn't lead by the file lock")
+			}
+			if len(fenced) != 0 {
+				t.Fatal("the fence ran for leadership by the file lock")
+			}
+
+			down.Store(false)
+			select {
+			case term := <-fenced:
+				if term != 1 {
+					t.Fatalf("fenced term %d, want the term 1 granted by the database", term)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("the term granted by the database wasn't fenced")
+			}
+			time.Sleep(30 * time.Millisecond)
+			if fenceErr == nil {
+				if !e.IsLeaderCached() || lost.Load() != 0 {
+					t.Fatalf("IsLeaderCached() = %v with %d losses, want leadership kept through the database",
+						e.IsLeaderCached(), lost.Load())
+				}
+				if !slices.ContainsFunc(db.received(), func(q fakeQuery) bool {
+					return strings.Contains(q.sql, "SET correlation_id = ?")
+				}) {
+					t.Fatal("the correlation ID wasn't stamped on the term granted by the database")
+				}
+			} else if lost.Load() == 0 {
+				t.Fatal("the candidate kept leading after the fence rejected its term")
+			}
+			cancel()
+			<-stopped
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package leaderelection
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// HistoryEntry records a candidate taking over the leadership of an election, starting a new term.
+type HistoryEntry struct {
+	ID           uint   `gorm:"primaryKey"`
+	ElectionName string `gorm:"uniqueIndex:uidx_election_term"`
+	LeaderName   string
+	Term         uint64    `gorm:"uniqueIndex:uidx_election_term"`
+	AcquiredAt   time.Time `gorm:"index"`
+}
+
+func (HistoryEntry) TableName() string {
+	return "election_history"
+}
+
+// History returns up to limit of the most recent leadership takeovers of the election, newest first. Takeovers are
+// only recorded by candidates running WithHistory. A limit that isn't positive is rejected with ErrInvalidConfig.
+func (e *Election) History(ctx context.Context, limit int) ([]HistoryEntry, error) {
+	ctx = orBackground(ctx)
+	if err := e.checkOpen(); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		return nil, fmt.Errorf("%w: history limit must be positive, got %d", ErrInvalidConfig, limit)
+	}
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	if e.memory != nil {
+		return nil, errMemoryUnsupported("History")
+	}
+	var entries []HistoryEntry
+	sql := `SELECT id, election_name, leader_name, term, acquired_at FROM {history}
+			WHERE election_name=? ORDER BY term DESC LIMIT ?`
+	if err := e.conn(ctx).Raw(e.readSQL(sql), e.storedName, limit).Scan(&entries).Error; err != nil {
+		return nil, ctxError(ctx, err)
+	}
+	return entries, nil
+}
+
+// WatchTransitions streams the leadership takeovers of the election as they are recorded, for an audit feed or an
+// external monitor: it polls the history table every renew interval and emits each takeover newer than the ones
+// recorded when it was called, oldest first, with its term and the time it happened. Takeovers are only recorded by
+// candidates running WithHistory. The channel is closed once ctx is done or the election is closed; polling errors
+// are logged and retried.
+func (e *Election) WatchTransitions(ctx context.Context) (<-chan HistoryEntry, error) {
+	ctx = orBackground(ctx)
+	if err := e.checkOpen(); err != nil {
+		return nil, err
+	}
+	if e.memory != nil {
+		return nil, errMemoryUnsupported("WatchTransitions")
+	}
+	latest, err := e.History(ctx, 1)
+	if err != nil {
+		return nil, err
+	}
+	var term uint64
+	if len(latest) > 0 {
+		term = latest[0].Term
+	}
+	ch := make(chan HistoryEntry)
+	go func() {
+		defer close(ch)
+		for sleep(ctx, e.opts.renewInterval) == nil {
+			if e.checkOpen() != nil {
+				return
+			}
+			entries, err := e.historySince(ctx, term)
+			if err != nil {
+				if ctx.Err() == nil {
+					e.logEvent(ctx, slog.LevelWarn, "failed to poll the election history", OutcomeError, slog.Any("error", err))
+				}
+				continue
+			}
+			for _, entry := range entries {
+				select {
+				case ch <- entry:
+					term = entry.Term
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// historySince returns the takeovers of the election after term, oldest first.
+func (e *Election) historySince(ctx context.Context, term uint64) ([]HistoryEntry, error) {
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	var entries []HistoryEntry
+	sql := `SELECT id, election_name, leader_name, term, acquired_at FROM {history}
+			WHERE election_name=? and term > ? ORDER BY term`
+	if err := e.conn(ctx).Raw(e.readSQL(sql), e.storedName, term).Scan(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// recordHistory copies the term this candidate just won into the history table. Each term is recorded once, so
+// renewals of a term already recorded leave the history untouched.
+func (e *Election) recordHistory(db *gorm.DB) error {
+	sql := `INSERT IGNORE INTO {history} (election_name, leader_name, term, acquired_at)
+			SELECT election_name, leader_name, term, last_update FROM {records} WHERE election_name=? and ` + isCandidate
+	return db.Exec(e.writeSQL(sql), e.storedName, e.candidate).Error
+}
@@ -0,0 +1,80 @@
+package leaderelection
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWinsRecordedOncePerTerm(t *testing.T) {
+	for name, opts := range map[string][]Option{
+		"read back": {WithHistory(), WithStats()},
+		"fast path": {WithHistory(), WithStats(), WithRowsAffectedFastPath()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var term atomic.Int64
+			term.Store(1)
+			var history, stats atomic.Int32
+			db := &fakeDB{handle: func(query fakeQuery) (*fakeResult, error) {
+				switch {
+				case strings.HasPrefix(query.sql, "INSERT INTO election_records"):
+					return &fakeResult{rowsAffected: 2}, nil
+				case strings.HasPrefix(query.sql, "SELECT leader_name, "):
+					return row([]string{"leader_name", "term", "held"}, "candidate", term.Load(), true), nil
+				case strings.HasPrefix(query.sql, "INSERT IGNORE INTO election_history"):
+					history.Add(1)
+				case strings.HasPrefix(query.sql, "INSERT INTO election_stats"):
+					stats.Add(1)
+				}
+				return nil, nil
+			}}
+			e := newFakeElection(t, db, opts...)
+			campaign := func() {
+				t.Helper()
+				if won, err := e.Campaign(context.Background()); err != nil || !won {
+					t.Fatalf("Campaign() = %v, %v, want true", won, err)
+				}
+			}
+			for range 3 {
+				campaign()
+			}
+			if history.Load() != 1 || stats.Load() != 1 {
+				t.Fatalf("renewing term 1 wrote history %d and stats %d times, want once each", history.Load(), stats.Load())
+			}
+			term.Store(2)
+			campaign()
+			if history.Load() != 2 || stats.Load() != 2 {
+				t.Fatalf("winning term 2 wrote history %d and stats %d times in all, want twice each", history.Load(),
+					stats.Load())
+			}
+		})
+	}
+}
+
+func TestHistoryLimit(t *testing.T) {
+	db := &fakeDB{}
+	e := newFakeElection(t, db, WithHistory())
+	for _, limit := range []int{0, -1} {
+		if _, err := e.History(context.Background(), limit); !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("History(%d) = %v, want ErrInvalidConfig", limit, err)
+		}
+	}
+	if queries := db.received(); len(queries) != 0 {
+		t.Fatalf("History sent %d statements for invalid limits, want none", len(queries))
+	}
+}
+
+func TestHistoryReportsContextErrors(t *testing.T) {
+	e := newFakeElection(t, &fakeDB{handle: func(query fakeQuery) (*fakeResult, error) {
+		<-query.ctx.Done()
+		return nil, errKilledConn
+	}}, WithHistory())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := e.History(ctx, 10); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("History() = %v, want context.DeadlineExceeded", err)
+	}
+}
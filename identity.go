@@ -0,0 +1,218 @@
+package leaderelection
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// IdentityProvider names the candidate a process campaigns as. Identities must be unique among the candidates of an
+// election, and stable for as long as the process participates in it.
+type IdentityProvider interface {
+	Identity() (string, error)
+}
+
+// IdentityFunc adapts an ordinary function to an IdentityProvider.
+type IdentityFunc func() (string, error)
+
+func (f IdentityFunc) Identity() (string, error) {
+	return f()
+}
+
+// HostIdentity is the default provider, naming the candidate worker/<hostname>/<WorkerID>. Failing to list the network
+// interfaces is logged rather than failing the identity: through the logger of WithLogger when RunElection names the
+// candidate, or slog's default logger when Identity is called directly.
+func HostIdentity() IdentityProvider {
+	return HostIdentityFrom(os.Hostname)
+}
+
+// HostIdentityFrom is HostIdentity with the hostname taken from hostname instead of os.Hostname. If hostname fails,
+// the candidate is named after unknown-<random token> instead, so hosts that can't resolve their names don't collide on
+// a shared "unknown" identity; the fallback is logged like HostIdentity's. The identity is derived once, so it stays
+// stable for the life of the provider.
+func HostIdentityFrom(hostname func() (string, error)) IdentityProvider {
+	return hostIdentity(hostname, loggedWorkerID(WorkerID))
+}
+
+// HostIdentityWithoutMAC names the candidate worker/<hostname>/<random token>, like HostIdentity but without listing
+// the network interfaces, for containers where enumerating them is restricted or meaningless: it avoids the syscall,
+// its latency and the warning logged when it fails. The token is drawn once, so the identity is stable for the life of
+// the provider, but unlike WorkerID it differs between runs of the same process. See WithoutMACLookup.
+func HostIdentityWithoutMAC() IdentityProvider {
+	return hostIdentity(os.Hostname, func(*slog.Logger) (string, error) {
+		return randomToken()
+	})
+}
+
+// loggedWorkerID makes a worker id derived from the PID alone, as WorkerID returns it when the network interfaces
+// can't be listed, a warning rather than an error.
+func loggedWorkerID(workerID func() (string, error)) func(logger *slog.Logger) (string, error) {
+	return func(logger *slog.Logger) (string, error) {
+		id, err := workerID()
+		if err != nil {
+			logger.Warn("deriving the worker id from the process ID alone", slog.Any("error", err))
+		}
+		return id, nil
+	}
+}
+
+// hostIdentityProvider names the candidate worker/<hostname>/<worker id>, deriving both once.
+type hostIdentityProvider struct {
+	hostname func() (string, error)
+	workerID func(logger *slog.Logger) (string, error)
+
+	once sync.Once
+	id   string
+	err  error
+}
+
+func hostIdentity(hostname func() (string, error), workerID func(logger *slog.Logger) (string, error)) IdentityProvider {
+	return &hostIdentityProvider{hostname: hostname, workerID: workerID}
+}
+
+func (p *hostIdentityProvider) Identity() (string, error) {
+	return p.identity(slog.Default())
+}
+
+// identity derives the identity on the first call, logging through logger.
+func (p *hostIdentityProvider) identity(logger *slog.Logger) (string, error) {
+	p.once.Do(func() {
+		p.id, p.err = p.derive(logger)
+	})
+	return p.id, p.err
+}
+
+func (p *hostIdentityProvider) derive(logger *slog.Logger) (string, error) {
+	host, err := p.hostname()
+	if err != nil {
+		token, tokenErr := randomToken()
+		if tokenErr != nil {
+			return "", fmt.Errorf("failed to determine hostname (%w) or a fallback for it: %w", err, tokenErr)
+		}
+		host = "unknown-" + token
+		logger.Warn("failed to determine hostname, using a random one", slog.String("hostname", host),
+			slog.Any("error", err))
+	}
+	id, err := p.workerID(logger)
+	if err != nil {
+		return "", fmt.Errorf("failed to derive the worker id: %w", err)
+	}
+	return fmt.Sprintf("worker/%s/%s", host, id), nil
+}
+
+// identify names the candidate through provider. The providers of this package that log do so through logger.
+func identify(provider IdentityProvider, logger *slog.Logger) (string, error) {
+	if p, ok := provider.(*hostIdentityProvider); ok {
+		return p.identity(logger)
+	}
+	return provider.Identity()
+}
+
+func randomToken() (string, error) {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b[:]), nil
+}
+
+// StaticIdentity always names the candidate name.
+func StaticIdentity(name string) IdentityProvider {
+	return IdentityFunc(func() (string, error) {
+		return name, nil
+	})
+}
+
+// UUIDIdentity names the candidate with a random UUID, generated once so the identity is stable for the life of the
+// provider.
+func UUIDIdentity() IdentityProvider {
+	id, err := newUUID()
+	return IdentityFunc(func() (string, error) {
+		return id, err
+	})
+}
+
+// KubernetesPodIdentity names the candidate <namespace>/<pod> from the POD_NAMESPACE and POD_NAME environment
+// variables, which should be populated through the downward API. Pod names are unique within a namespace, so this
+// avoids the MAC address collisions HostIdentity can suffer from inside containers.
+func KubernetesPodIdentity() IdentityProvider {
+	return IdentityFunc(func() (string, error) {
+		namespace, pod := os.Getenv("POD_NAMESPACE"), os.Getenv("POD_NAME")
+		if namespace == "" || pod == "" {
+			return "", fmt.Errorf("POD_NAMESPACE and POD_NAME must be set through the downward API")
+		}
+		return namespace + "/" + pod, nil
+	})
+}
+
+// KubernetesIdentity names the candidate <namespace>/<pod>/<uid> from the POD_NAMESPACE, POD_NAME and POD_UID
+// environment variables. Unlike KubernetesPodIdentity, it tells apart successive pods reusing a name, as StatefulSet
+// pods do. Select it with WithIdentityProvider(IdentityFunc(KubernetesIdentity)). The variables should be populated
+// through the downward API:
+//
+//	env:
+//	- name: POD_NAMESPACE
+//	  valueFrom: {fieldRef: {fieldPath: metadata.namespace}}
+//	- name: POD_NAME
+//	  valueFrom: {fieldRef: {fieldPath: metadata.name}}
+//	- name: POD_UID
+//	  valueFrom: {fieldRef: {fieldPath: metadata.uid}}
+func KubernetesIdentity() (string, error) {
+	var missing []string
+	values := make([]string, 0, 3)
+	for _, name := range []string{"POD_NAMESPACE", "POD_NAME", "POD_UID"} {
+		value := os.Getenv(name)
+		if value == "" {
+			missing = append(missing, name)
+		}
+		values = append(values, value)
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("%s must be set from metadata.namespace, metadata.name and metadata.uid through the "+
+			"downward API", strings.Join(missing, ", "))
+	}
+	return strings.Join(values, "/"), nil
+}
+
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("failed to generate uuid: %w", err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// WorkerID derives the worker id HostIdentity uses: a hash of the MAC addresses of this host's network interfaces and
+// the process ID. If the interfaces can't be listed it still returns an id, derived from the PID alone, along with the
+// error.
+func WorkerID() (string, error) {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		err = fmt.Errorf("failed to list network interfaces: %w", err)
+	}
+	return WorkerIDFor(interfaces, os.Getpid()), err
+}
+
+// WorkerIDFor derives a worker id from the given network interfaces and process ID the way WorkerID does from the
+// real ones. It is deterministic, so ids can be computed for other hosts or tested without real network interfaces.
+func WorkerIDFor(interfaces []net.Interface, pid int) string {
+	var parts []string
+	for _, ifa := range interfaces {
+		if addr := ifa.HardwareAddr.String(); addr != "" {
+			parts = append(parts, addr)
+		}
+	}
+	parts = append(parts, strconv.Itoa(pid))
+	hash := md5.New()
+	hash.Write([]byte(strings.Join(parts, ",")))
+	return hex.EncodeToString(hash.Sum(nil))
+}
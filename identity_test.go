@@ -0,0 +1,35 @@
+package leaderelection
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestHostIdentityLogsThroughLogger(t *testing.T) {
+	var logs bytes.Buffer
+	provider := hostIdentity(func() (string, error) { return "host", nil }, loggedWorkerID(func() (string, error) {
+		return "pid-only", errors.New("no network interfaces")
+	}))
+	id, err := identify(provider, slog.New(slog.NewTextHandler(&logs, nil)))
+	if err != nil || id != "worker/host/pid-only" {
+		t.Fatalf("identify() = %q, %v, want worker/host/pid-only", id, err)
+	}
+	if !strings.Contains(logs.String(), "deriving the worker id from the process ID alone") {
+		t.Fatalf("the worker id fallback wasn't logged through the given logger:\n%s", logs.String())
+	}
+}
+
+func TestHostnameFallbackLogsThroughLogger(t *testing.T) {
+	var logs bytes.Buffer
+	provider := HostIdentityFrom(func() (string, error) { return "", errors.New("no hostname") })
+	id, err := identify(provider, slog.New(slog.NewTextHandler(&logs, nil)))
+	if err != nil || !strings.HasPrefix(id, "worker/unknown-") {
+		t.Fatalf("identify() = %q, %v, want a worker/unknown-<token> identity", id, err)
+	}
+	if !strings.Contains(logs.String(), "failed to determine hostname, using a random one") {
+		t.Fatalf("the hostname fallback wasn't logged through the given logger:\n%s", logs.String())
+	}
+}
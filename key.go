@@ -0,0 +1,20 @@
+package leaderelection
+
+import (
+	"net/url"
+)
+
+// Key is a structured election name made of labels, e.g. Key{"tenant": "a", "job": "b"}, for elections that are
+// naturally keyed by several attributes.
+type Key map[string]string
+
+// String returns the canonical election name for the key: labels sorted by name and query-escaped, so the same labels
+// always map to the same election and no label value can forge a delimiter. Pass it as the election name to
+// NewElection or RunElection.
+func (k Key) String() string {
+	values := make(url.Values, len(k))
+	for name, value := range k {
+		values.Set(name, value)
+	}
+	return values.Encode()
+}
@@ -2,42 +2,146 @@ package leaderelection
 
 import (
 	"context"
-	"crypto/md5"
+	"crypto/sha256"
+	"database/sql"
 	"encoding/hex"
+	"errors"
 	"fmt"
-	"log"
-	"net"
-	"os"
-	"strconv"
+	"log/slog"
+	"math/rand/v2"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
-	"github.com/joho/godotenv"
+	mysqldriver "github.com/go-sql-driver/mysql"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
 
+// ElectionRecord is the row candidates of an election compete for. The unique index on ElectionName is what makes
+// concurrent campaigns converge on one row, even for the very first campaign of a new election.
 type ElectionRecord struct {
-	ID           uint   `gorm:"primary_key"`
-	ElectionName string `gorm:"unique_index:uidx_election_name"`
+	ID           uint   `gorm:"primaryKey"`
+	ElectionName string `gorm:"uniqueIndex:uidx_election_name"`
 	LeaderName   string
+	Term         uint64    `gorm:"not null;default:0"`
 	LastUpdate   time.Time `gorm:"autoCreateTime"`
+	// HoldUntil is when the minimum hold period of the current leader's acquisition ends, see WithMinHoldDuration.
+	HoldUntil *time.Time
+	// OriginalName is the full election name, for names stored hashed by WithLongNameHashing.
+	OriginalName string `gorm:"type:text"`
+	// CorrelationID is the correlation ID the leader stamped on acquiring the lease, see WithCorrelationID.
+	CorrelationID string
+	// ExpiresAtUnix is when the lease expires, in seconds since the Unix epoch, for elections run WithEpochLease.
+	ExpiresAtUnix int64 `gorm:"not null;default:0"`
 }
 
+// maxElectionNameLength is the size of the election_name column.
+const maxElectionNameLength = 256
+
 type Election struct {
-	ElectionName string
-	LeaderName   string
-	db           *gorm.DB
+	// name and candidate are fixed at construction: leases are held under them, so changing them would desync the
+	// lease from the candidate's view of it.
+	name       string
+	candidate  string
+	storedName string
+	db         *gorm.DB
+	opts       options
+	logger     *slog.Logger
+	term       atomic.Uint64
+	renewedAt  atomic.Int64
+	leading    atomic.Bool
+	token      atomic.Pointer[string]
+	recorded   atomic.Uint64
+	aliases    atomic.Pointer[[]string]
+	counters   electionCounters
+	reserved   atomic.Pointer[gorm.DB]
+	events     broadcaster
+	tables     *strings.Replacer
+	memory     *MemoryRegistry
+	batch      *renewBatch
+	campaigns  campaignLimiter
+	// lifecycle serialises Resign and Close against the campaigns and renewals in flight, and guards resigned
+	lifecycle sync.RWMutex
+	resigned  bool
+	closed    atomic.Bool
+	// ownsDB is set when the election opened db itself, rather than sharing the pool of a Manager
+	ownsDB bool
+	// multiStatements is set when the DSN lets statements be sent together: it enables multiStatements, and
+	// interpolateParams, as server-side prepared statements can only hold one statement.
+	multiStatements bool
+	redactedDSN     string
+}
+
+// ElectionName returns the name of the election, as given to its constructor.
+func (e *Election) ElectionName() string {
+	return e.name
+}
+
+// Candidate returns the name this candidate campaigns under.
+func (e *Election) Candidate() string {
+	return e.candidate
+}
+
+// IsLeaderCached reports whether e's Run loop currently considers itself the leader, as of its last transition,
+// without querying the database. It is a single atomic load, free of locks and allocations, so it can gate
+// per-request behaviour in high-throughput services; use IsLeader where the lease has to be verified.
+func (e *Election) IsLeaderCached() bool {
+	return e.leading.Load()
+}
+
+// LeadershipToken returns the token of e's current leadership streak, or "" while its Run loop doesn't lead. A new
+// token is generated every time the loop acquires leadership, and kept across renewals, so a token that changed
+// between two reads reveals that leadership was lost and regained in between, however briefly. Unlike the term, the
+// token is local to this process.
+func (e *Election) LeadershipToken() string {
+	if token := e.token.Load(); token != nil {
+		return *token
+	}
+	return ""
 }
 
+// newLeadershipToken starts a new leadership streak for LeadershipToken.
+func (e *Election) newLeadershipToken() {
+	token, err := newUUID()
+	if err != nil {
+		token = fmt.Sprintf("%s-%d", e.candidate, time.Now().UnixNano())
+	}
+	e.token.Store(&token)
+}
+
+// Elector is the contract elections offer regardless of where their leases are stored: Election implements it against
+// MySQL, or in process when created with NewMemoryElection.
+type Elector interface {
+	Campaign(ctx context.Context) (bool, error)
+	Renew(ctx context.Context) (bool, error)
+	IsLeader(ctx context.Context) (bool, error)
+	GetLeader(ctx context.Context) (string, error)
+	Run(ctx context.Context, becomeLeaderCb CallbackFunc, looseLeadershipCB CallbackFunc) error
+}
+
+var _ Elector = (*Election)(nil)
+
+// Every lease decision compares last_update against the server clock with the same arithmetic, so a row the campaign
+// refuses to take over is exactly a row that renewal and verification still consider held. MySQLBuilder inlines the
+// lease in the same conditions.
+
+// isCandidate matches the row held by the given candidate. Candidate names are compared as binary strings whatever
+// the collation of leader_name, so names differing only by case (Worker/A and worker/a) are distinct candidates.
+const isCandidate = `leader_name = CAST(? AS BINARY)`
+
 // NewElection Starts a new election with the given name, and candidate name. Multiple candidates can try to win a given
-// election name, but only one of them would succeed.
+// election name, but only one of them would succeed. Empty or blank names are rejected with ErrInvalidConfig.
 // Inspired from https://gist.github.com/ljjjustin/f2213ac9b9b8c31df746f8b56095ea32
-func NewElection(name string, candidate string, config map[string]string) (*Election, error) {
-	var err error
-	election := Election{ElectionName: name, LeaderName: candidate}
-	mysqlDSN := fmt.Sprintf(
+func NewElection(name string, candidate string, config map[string]string, opts ...Option) (*Election, error) {
+	return NewElectionWithDSN(name, candidate, configDSN(config), opts...)
+}
+
+// configDSN builds the DSN for the MYSQL_* configuration keys.
+func configDSN(config map[string]string) string {
+	return fmt.Sprintf(
 		"%s:%s@tcp(%s:%s)/%s?charset=utf8&parseTime=True&loc=Local",
 		config["MYSQL_USER"],
 		config["MYSQL_PASSWORD"],
@@ -45,16 +149,52 @@ func NewElection(name string, candidate string, config map[string]string) (*Elec
 		config["MYSQL_PORT"],
 		config["MYSQL_DBNAME"],
 	)
+}
+
+// NewElectionWithDSN is NewElection for a fully-formed go-sql-driver/mysql DSN, for connection settings (TLS, timeouts,
+// ...) the structured config can't express. The DSN must enable parseTime.
+func NewElectionWithDSN(name string, candidate string, dsn string, opts ...Option) (*Election, error) {
+	election, err := newElection(name, candidate, opts)
+	if err != nil {
+		return nil, err
+	}
+	if election.db, err = openDB(dsn, election.opts); err != nil {
+		return nil, err
+	}
+	election.ownsDB = true
+	election.multiStatements = multiStatementsDSN(dsn)
+	election.redactedDSN = redactDSN(dsn)
+	if err = election.attach(); err != nil {
+		return nil, err
+	}
+	return election, nil
+}
 
-	election.db, err = gorm.Open(mysql.New(mysql.Config{
-		DSN:               mysqlDSN,
+// openDB opens the connection pool elections query.
+func openDB(dsn string, o options) (*gorm.DB, error) {
+	if len(o.sessionVars) > 0 {
+		// the driver sets the DSN's unknown parameters as session variables on every new connection
+		cfg, err := mysqldriver.ParseDSN(dsn)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to parse the DSN to add session variables: %w", ErrInvalidConfig, err)
+		}
+		if cfg.Params == nil {
+			cfg.Params = make(map[string]string, len(o.sessionVars))
+		}
+		for name, value := range o.sessionVars {
+			cfg.Params[name] = value
+		}
+		dsn = cfg.FormatDSN()
+	}
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:               dsn,
 		DefaultStringSize: 256,
-	}), &gorm.Config{})
+	}), &gorm.Config{NamingStrategy: o.namingStrategy})
 	if err != nil {
 		return nil, err
 	}
 
-	sqlDB, err := election.db.DB()
+	sqlDB, err := db.DB()
 	if err != nil {
 		return nil, err
 	}
@@ -62,113 +202,998 @@ func NewElection(name string, candidate string, config map[string]string) (*Elec
 	sqlDB.SetConnMaxLifetime(1 * time.Hour)
 	sqlDB.SetMaxIdleConns(2)
 	sqlDB.SetMaxOpenConns(10)
+	return db, nil
+}
 
-	if err = election.db.AutoMigrate(&ElectionRecord{}); err != nil {
-		return nil, fmt.Errorf("failed to create/update db tables with error %s", err.Error())
+func multiStatementsDSN(dsn string) bool {
+	cfg, err := mysqldriver.ParseDSN(dsn)
+	return err == nil && cfg.MultiStatements && cfg.InterpolateParams
+}
+
+// attach prepares the election to run against its db: it resolves the table names and creates the tables.
+func (e *Election) attach() error {
+	if err := e.resolveTables(); err != nil {
+		return err
+	}
+	if e.opts.autoMigrate {
+		return e.migrate(context.Background())
+	}
+	return nil
+}
+
+// newElection validates the options and sets up an election without a backend.
+func newElection(name string, candidate string, opts []Option) (*Election, error) {
+	o := newOptions(opts)
+	if err := o.validate(); err != nil {
+		return nil, err
 	}
+	if err := validateNames(name, candidate); err != nil {
+		return nil, err
+	}
+	storedName := name
+	if len(name) > maxElectionNameLength {
+		if !o.hashLongNames {
+			return nil, fmt.Errorf("%w: election name is %d characters long, the limit is %d (see WithLongNameHashing)",
+				ErrInvalidConfig, len(name), maxElectionNameLength)
+		}
+		storedName = hashedElectionName(name)
+	}
+	return &Election{
+		name:       name,
+		candidate:  candidate,
+		storedName: storedName,
+		opts:       o,
+		logger:     o.logger.With(slog.String(LogKeyElection, name), slog.String(LogKeyCandidate, candidate)),
+	}, nil
+}
 
-	return &election, nil
+// validateNames rejects an empty (or blank) election or candidate name: candidates sharing an empty name, say from a
+// templating mistake, would all hold the same lease and consider themselves leader.
+func validateNames(name string, candidate string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("%w: election name is empty", ErrInvalidConfig)
+	}
+	if strings.TrimSpace(candidate) == "" {
+		return fmt.Errorf("%w: candidate name is empty", ErrInvalidConfig)
+	}
+	return nil
 }
 
-// Campaign starts to attempt to win an election.
+// Campaign starts to attempt to win an election. Taking over the election from another (or an expired) leader starts
+// a new term. A campaign cut short by ctx, or the query timeout, returns an error wrapping the context error rather
+// than reporting a lost election; this holds for every method querying the election.
 func (e *Election) Campaign(ctx context.Context) (bool, error) {
-	sql := `INSERT IGNORE INTO election_records (election_name, leader_name, last_update) VALUES (?, ?, ?)
-			ON DUPLICATE KEY UPDATE
-			leader_name = IF(last_update < DATE_SUB(VALUES(last_update), INTERVAL 60 SECOND), VALUES(leader_name), leader_name),
-			last_update = IF(leader_name = VALUES(leader_name), VALUES(last_update), last_update)`
-	affected := e.db.Exec(sql, e.ElectionName, e.LeaderName, time.Now()).RowsAffected
-	if affected > 0 {
-		//good you are leader
-		return true, nil
-	} else {
-		// wait 20 seconds and campaign again
+	won, err := e.campaignRound(ctx)
+	if errors.Is(err, errAcquireBlocked) {
 		return false, nil
 	}
+	return won, err
 }
 
-func (e *Election) IsLeader(ctx context.Context) (bool, error) {
-	var count int
-	sql := `SELECT COUNT(*) as is_leader FROM election_records where election_name=? and leader_name=?`
-	e.db.Raw(sql, e.ElectionName, e.LeaderName).Scan(&count)
-	return count > 0, nil
+// campaignRound is Campaign, returning errAcquireBlocked for a campaign that gave up waiting for the row lock, so the
+// election loop can tell a leader's blocked renewal from a lost lease.
+func (e *Election) campaignRound(ctx context.Context) (bool, error) {
+	ctx = orBackground(ctx)
+	if err := validateNames(e.name, e.candidate); err != nil {
+		return false, err
+	}
+	done, err := e.participating()
+	if err != nil {
+		return false, err
+	}
+	defer done()
+	if e.memory != nil {
+		return e.memory.campaign(e), nil
+	}
+	started := time.Now()
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	acquireCtx, cancelAcquire := e.acquireContext(ctx)
+	defer cancelAcquire()
+	var won bool
+	err = e.acquireOnOneConn(acquireCtx, func(db *gorm.DB) (err error) {
+		won, err = e.campaign(acquireCtx, db)
+		return err
+	})
+	if err != nil {
+		if e.acquireBlocked(ctx, err) {
+			e.logEvent(ctx, slog.LevelDebug, "campaign blocked on the election row, giving up this round", OutcomeNotAcquired,
+				slog.Any("error", err))
+			e.observeOperation(OperationCampaign, started, nil)
+			return false, fmt.Errorf("%w: %w", errAcquireBlocked, err)
+		}
+		var retry bool
+		if retry, err = e.recoverMissingTable(ctx, err); retry {
+			err = e.acquireOnOneConn(acquireCtx, func(db *gorm.DB) (err error) {
+				won, err = e.campaign(acquireCtx, db)
+				return err
+			})
+		}
+	}
+	err = ctxError(ctx, err)
+	e.observeOperation(OperationCampaign, started, err)
+	return won, err
 }
 
-type CallbackFunc func()
+// Bootstrap claims leadership for a designated candidate on first deployment, e.g. the one with a warm cache, instead
+// of leaving it to a race: it acquires the lease if the election is empty or its lease expired, and returns nil if
+// this candidate holds it afterwards. It never takes over a valid lease, returning ErrLeaseHeld instead, so it is safe
+// to call on every start. Run the election straight after, so the lease is renewed before it expires.
+func (e *Election) Bootstrap(ctx context.Context) error {
+	ctx = orBackground(ctx)
+	won, err := e.Campaign(ctx)
+	if err != nil {
+		return err
+	}
+	if !won {
+		return ErrLeaseHeld
+	}
+	e.logEvent(ctx, slog.LevelInfo, "bootstrapped the election", OutcomeWon)
+	return nil
+}
 
-func ElectLeader(electionName string, becomeLeaderCb CallbackFunc, looseLeadershipCB CallbackFunc) {
-	hostname, err := os.Hostname()
+// CampaignTx is Campaign run within tx, a transaction of the caller's on the election database, so that winning the
+// election and the application's own writes in tx are committed, or rolled back, together. Nothing is visible to
+// other candidates until tx commits, and rolling it back undoes the acquisition.
+//
+// The acquisition locks the election row until tx ends: meanwhile other candidates' campaigns, and the current
+// leader's renewals, wait on the lock, up to innodb_lock_wait_timeout. A lease acquired or renewed in tx is timed from
+// the campaign statement, not the commit, so it has that much less left once committed. Keep tx short, and commit or
+// roll it back before the lease duration passes. The election table is never recreated from within tx, as DDL would
+// commit it.
+func (e *Election) CampaignTx(ctx context.Context, tx *gorm.DB) (bool, error) {
+	ctx = orBackground(ctx)
+	if err := validateNames(e.name, e.candidate); err != nil {
+		return false, err
+	}
+	done, err := e.participating()
 	if err != nil {
-		hostname = "unknown"
+		return false, err
+	}
+	defer done()
+	if e.memory != nil {
+		return false, errMemoryUnsupported("CampaignTx")
+	}
+	started := time.Now()
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	won, err := e.campaign(ctx, tx.WithContext(ctx))
+	err = ctxError(ctx, err)
+	e.observeOperation(OperationCampaign, started, err)
+	return won, err
+}
+
+// acquireContext bounds a campaign by the acquire timeout, if one is configured.
+func (e *Election) acquireContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if e.opts.acquireTimeout <= 0 {
+		return ctx, func() {}
 	}
-	workerName := fmt.Sprintf("worker/%s/%s", hostname, getWorkerId())
-	appConfig, err := godotenv.Read()
+	return context.WithTimeout(ctx, e.opts.acquireTimeout)
+}
+
+// errAcquireBlocked is returned by campaigns that gave up waiting for the row lock, see acquireBlocked. Campaign
+// reports them as not acquired this round.
+var errAcquireBlocked = errors.New("leaderelection: campaign blocked on the election row")
+
+// acquireBlocked reports whether a campaign failed only because it waited too long for the row lock: it hit the
+// acquire timeout (while ctx itself is still live) or InnoDB's lock wait timeout.
+func (e *Election) acquireBlocked(ctx context.Context, err error) bool {
+	if e.opts.acquireTimeout > 0 && errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+		return true
+	}
+	var mysqlErr *mysqldriver.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == errLockWaitTimeout
+}
+
+// CampaignOrFollow attempts to win the election and, if another candidate holds a valid lease, returns that leader's
+// name instead. Both happen in one transaction, so the returned leader is the one that beat this campaign; a lease that
+// has just expired is taken over rather than followed.
+func (e *Election) CampaignOrFollow(ctx context.Context) (bool, string, error) {
+	ctx = orBackground(ctx)
+	if err := validateNames(e.name, e.candidate); err != nil {
+		return false, "", err
+	}
+	done, err := e.participating()
+	if err != nil {
+		return false, "", err
+	}
+	defer done()
+	if e.memory != nil {
+		return e.memory.campaignOrFollow(e)
+	}
+	started := time.Now()
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	var won bool
+	var leader string
+	err = e.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if won, err = e.campaign(ctx, tx); err != nil || won {
+			leader = e.candidate
+			return err
+		}
+		// the upsert locked the row, so it still holds the leader that beat us
+		return tx.Raw(e.writeSQL(`SELECT leader_name FROM {records} where election_name=?`), e.storedName).Scan(&leader).Error
+	})
+	err = ctxError(ctx, err)
+	e.observeOperation(OperationCampaign, started, err)
 	if err != nil {
-		log.Fatalf("Error reading .env file %s", err.Error())
+		return false, "", err
 	}
+	return won, leader, nil
+}
 
-	election, _ := NewElection(electionName, workerName, appConfig)
-	ctx, _ := context.WithCancel(context.Background())
-	var isLeader int64 = 0
-	var wonCampaign bool
+// campaign runs the acquisition upsert on db, which may be the shared pool, a reserved connection or a transaction.
+func (e *Election) campaign(ctx context.Context, db *gorm.DB) (bool, error) {
+	sql := e.opts.sqlBuilder.Acquire(e.recordsTable(), e.leaseParams())
+	result := db.Exec(e.writeSQL(sql), e.storedName, e.candidate, e.name)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	won, term, err := e.campaignWon(db, result.RowsAffected)
+	if err != nil || !won {
+		return false, err
+	}
+	// renewals keep the term, which was recorded when it was won
+	if term != e.recorded.Swap(term) {
+		e.recordWin(ctx, db)
+	}
+	return true, nil
+}
 
-	log.Printf("Starting as candidate [%s] in election [%s].\n", workerName, electionName)
-	for {
-		if wonCampaign, err = election.Campaign(ctx); err != nil {
-			log.Fatalf("Failed in election.Campaign, error : %s\n", err.Error())
+// recordWin records the term this candidate won in the history and stats tables, if enabled. Failures are only logged,
+// so bookkeeping can't cost the candidate its lease.
+func (e *Election) recordWin(ctx context.Context, db *gorm.DB) {
+	if e.opts.history {
+		if err := e.recordHistory(db); err != nil {
+			e.logEvent(ctx, slog.LevelWarn, "failed to record leadership history", OutcomeError, slog.Any("error", err))
+		}
+	}
+	if e.opts.stats {
+		if err := e.recordStats(db); err != nil {
+			e.logEvent(ctx, slog.LevelWarn, "failed to record election stats", OutcomeError, slog.Any("error", err))
 		}
+	}
+}
 
-		if !wonCampaign {
-			if atomic.CompareAndSwapInt64(&isLeader, 1, 0) {
-				log.Printf("Oh No! [%s] lost leadership.\n", workerName)
-				looseLeadershipCB()
-			}
-			log.Printf("Failed to accuire leadership, will reattempt....\n")
-			time.Sleep(60 * time.Second)
-			continue
+// campaignWon settles whether the campaign upsert left this candidate holding the lease, and under which term, by
+// reading the lease back from db, which must be the connection or transaction the upsert ran on, so the answer can
+// neither lag behind the upsert nor be misread from a proxy's affected-row count. WithRowsAffectedFastPath trusts the
+// count instead where it is conclusive: 1 for an insert, 2 for an update and 0 for a row left unchanged, so a row is
+// only written when we are (now) the leader. Clients connecting with CLIENT_FOUND_ROWS (clientFoundRows=true) get 1
+// for an unchanged row too, so that count is always read back, and so is an update when the history or stats need
+// its term. The term is zero when it wasn't read back.
+func (e *Election) campaignWon(db *gorm.DB, rowsAffected int64) (bool, uint64, error) {
+	if e.opts.rowsAffectedFast {
+		switch {
+		case rowsAffected == 0:
+			return false, 0, nil
+		case rowsAffected == 2 && !e.opts.history && !e.opts.stats:
+			return true, 0, nil
 		}
+	}
+	var lease struct {
+		LeaderName string
+		Term       uint64
+		Held       bool
+	}
+	sql := `SELECT leader_name, term, ` + e.leaseParams().held() + ` AS held FROM {records} where election_name=?`
+	result := db.Raw(e.writeSQL(sql), e.storedName).Scan(&lease)
+	if result.Error != nil {
+		return false, 0, fmt.Errorf("failed to check lease ownership: %w", result.Error)
+	}
+	return result.RowsAffected > 0 && lease.Held && lease.LeaderName == e.candidate, lease.Term, nil
+}
+
+// acquireOnOneConn runs fn on a single connection of the shared pool, so the campaign upsert and its read-back see the
+// same session. Reserved connections already are one.
+func (e *Election) acquireOnOneConn(ctx context.Context, fn func(db *gorm.DB) error) error {
+	if e.opts.rowsAffectedFast || e.reserved.Load() != nil {
+		return fn(e.conn(ctx))
+	}
+	return e.db.WithContext(ctx).Connection(fn)
+}
 
-		//double check.
-		verifyLeadership, err := election.IsLeader(ctx)
+// campaignAndVerify campaigns and reads back the lease in one exchange with the server when the connection allows
+// multi-statements, or else in one transaction, reporting whether this candidate holds the lease afterwards and
+// remembering its term like IsLeader. Like campaignRound, it returns errAcquireBlocked for a campaign blocked on the
+// row lock.
+func (e *Election) campaignAndVerify(ctx context.Context) (bool, error) {
+	done, err := e.participating()
+	if err != nil {
+		return false, err
+	}
+	defer done()
+	if e.memory != nil {
+		return e.memory.campaign(e) && e.memory.isLeader(e), nil
+	}
+	started := time.Now()
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	acquireCtx, cancelAcquire := e.acquireContext(ctx)
+	defer cancelAcquire()
+	won, err := e.acquireVerified(acquireCtx)
+	if err != nil {
+		if e.acquireBlocked(ctx, err) {
+			e.logEvent(ctx, slog.LevelDebug, "campaign blocked on the election row, giving up this round", OutcomeNotAcquired,
+				slog.Any("error", err))
+			e.observeOperation(OperationCampaign, started, nil)
+			return false, fmt.Errorf("%w: %w", errAcquireBlocked, err)
+		}
+		var retry bool
+		if retry, err = e.recoverMissingTable(ctx, err); retry {
+			won, err = e.acquireVerified(acquireCtx)
+		}
+	}
+	e.observeOperation(OperationCampaign, started, err)
+	return won, err
+}
+
+func (e *Election) acquireVerified(ctx context.Context) (bool, error) {
+	acquire := e.writeSQL(e.opts.sqlBuilder.Acquire(e.recordsTable(), e.leaseParams()))
+	verify := e.writeSQL(e.opts.sqlBuilder.IsLeader(e.recordsTable(), e.leaseParams()))
+	var term uint64
+	held := false
+	if e.multiStatements {
+		rows, err := e.conn(ctx).Raw(acquire+";\n"+verify,
+			e.storedName, e.candidate, e.name, e.storedName, e.candidate).Rows()
 		if err != nil {
-			log.Fatalf("Failed in election.Campaign, error : %s\n", err.Error())
+			return false, err
 		}
-		if !verifyLeadership {
-			log.Printf("Failed to verify leadership candidate [%s] in election [%s]. Will reattempt...\n", workerName, electionName)
-			continue
+		defer rows.Close()
+		// the first result is the upsert's, the second the lease it left behind
+		if rows.NextResultSet() && rows.Next() {
+			if err = rows.Scan(&term); err != nil {
+				return false, err
+			}
+			held = true
 		}
-		if atomic.CompareAndSwapInt64(&isLeader, 0, 1) {
-			log.Printf("Yeaaah! [%s] won and is the leader.\n", workerName)
-			becomeLeaderCb()
+		if err = rows.Err(); err != nil {
+			return false, err
 		}
-		time.Sleep(15 * time.Second)
-		//log.Printf("Ensuring leadership....\n")
+	} else {
+		err := e.conn(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(acquire, e.storedName, e.candidate, e.name).Error; err != nil {
+				return err
+			}
+			result := tx.Raw(verify, e.storedName, e.candidate).Scan(&term)
+			held = result.RowsAffected > 0
+			return result.Error
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+	if !held {
+		return false, nil
+	}
+	if previous := e.term.Swap(term); term != previous {
+		e.recordWin(ctx, e.conn(ctx))
 	}
+	return true, nil
 }
 
-func getWorkerId() string {
-	addrs, err := getMacAddr()
+// Renew extends the lease held by this candidate. It reports false when the lease was lost or has already expired, in
+// which case leadership has to be won again through Campaign.
+func (e *Election) Renew(ctx context.Context) (bool, error) {
+	ctx = orBackground(ctx)
+	if err := validateNames(e.name, e.candidate); err != nil {
+		return false, err
+	}
+	done, err := e.participating()
 	if err != nil {
-		log.Printf("Error geting macAddr %s \n", err.Error())
-		addrs = []string{}
+		return false, err
+	}
+	defer done()
+	if e.memory != nil {
+		return e.memory.renew(e), nil
+	}
+	started := time.Now()
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	renewed, err := e.renew(ctx)
+	if err != nil {
+		var retry bool
+		if retry, err = e.recoverMissingTable(ctx, err); retry {
+			renewed, err = e.renew(ctx)
+		}
 	}
-	addrs = append(addrs, strconv.Itoa(os.Getpid()))
-	hash := md5.New()
-	hash.Write([]byte(strings.Join(addrs, ",")))
-	sha := hex.EncodeToString(hash.Sum(nil))
-	return sha
+	err = ctxError(ctx, err)
+	e.observeOperation(OperationRenew, started, err)
+	return renewed, err
 }
 
-func getMacAddr() ([]string, error) {
-	ifas, err := net.Interfaces()
+func (e *Election) renew(ctx context.Context) (bool, error) {
+	sql := e.opts.sqlBuilder.Renew(e.recordsTable(), e.leaseParams())
+	result := e.conn(ctx).Exec(e.writeSQL(sql), e.storedName, e.candidate)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return true, nil
+	}
+	return e.adoptAlias(ctx)
+}
+
+// RenewAndVerifyTerm extends the lease only if this candidate holds it under expectedTerm, so a leader using the term
+// as a fencing token learns it was superseded instead of renewing a lease taken over since. It returns ErrLeaseLost if
+// this candidate doesn't hold a valid lease, or holds it under another term.
+func (e *Election) RenewAndVerifyTerm(ctx context.Context, expectedTerm uint64) error {
+	ctx = orBackground(ctx)
+	done, err := e.participating()
 	if err != nil {
+		return err
+	}
+	defer done()
+	if e.memory != nil {
+		if !e.memory.renewTerm(e, expectedTerm) {
+			return ErrLeaseLost
+		}
+		return nil
+	}
+	started := time.Now()
+	err = e.renewTerm(ctx, expectedTerm)
+	if errors.Is(err, ErrLeaseLost) {
+		// the database answered: the lease is lost, like a renewal reporting false
+		e.observeOperation(OperationRenew, started, nil)
+	} else {
+		e.observeOperation(OperationRenew, started, err)
+	}
+	return err
+}
+
+func (e *Election) renewTerm(ctx context.Context, expectedTerm uint64) error {
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	sql := e.opts.sqlBuilder.RenewTerm(e.recordsTable(), e.leaseParams())
+	result := e.conn(ctx).Exec(e.writeSQL(sql), e.storedName, e.candidate, expectedTerm)
+	if result.Error != nil {
+		return ctxError(ctx, result.Error)
+	}
+	if result.RowsAffected > 0 {
+		return nil
+	}
+	// a renewal within the second it was last renewed in leaves the row unchanged, so no rows doesn't tell on its own
+	var term uint64
+	sql = e.opts.sqlBuilder.IsLeader(e.recordsTable(), e.leaseParams())
+	result = e.conn(ctx).Raw(e.writeSQL(sql), e.storedName, e.candidate).Scan(&term)
+	if result.Error != nil {
+		return ctxError(ctx, result.Error)
+	}
+	if result.RowsAffected == 0 || term != expectedTerm {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// Resign gives up the lease held by this candidate, so other candidates can take over without waiting for it to
+// expire. The row is kept, so the next leader still starts a new, higher term. Resigning without holding the lease is
+// a no-op.
+//
+// Resigning ends the candidate's participation: Resign waits for the campaigns and renewals in flight to finish, so
+// none of them can take back the lease it gives up, and from then on campaigns and renewals return ErrResigned, and a
+// running election loop stops with it, until Rejoin or running the election again.
+func (e *Election) Resign(ctx context.Context) error {
+	ctx = orBackground(ctx)
+	e.lifecycle.Lock()
+	defer e.lifecycle.Unlock()
+	if err := e.checkOpen(); err != nil {
+		return err
+	}
+	e.resigned = true
+	return e.resign(ctx)
+}
+
+// Rejoin lets a candidate that resigned campaign and renew again.
+func (e *Election) Rejoin() {
+	e.lifecycle.Lock()
+	defer e.lifecycle.Unlock()
+	e.resigned = false
+}
+
+// participating holds off Resign and Close while a campaign or renewal runs, until the returned func is called, or
+// returns ErrElectionClosed if the election was closed, or ErrResigned if the candidate resigned.
+func (e *Election) participating() (func(), error) {
+	e.lifecycle.RLock()
+	if err := e.checkOpen(); err != nil {
+		e.lifecycle.RUnlock()
 		return nil, err
 	}
-	var as []string
-	for _, ifa := range ifas {
-		a := ifa.HardwareAddr.String()
-		if a != "" {
-			as = append(as, a)
+	if e.resigned {
+		e.lifecycle.RUnlock()
+		return nil, ErrResigned
+	}
+	return e.lifecycle.RUnlock, nil
+}
+
+// Close releases the election: it closes the connection pool the election opened, leaving the pool of a Manager open
+// for its other elections, and from then on the election's methods return ErrElectionClosed, and a running election
+// loop stops with it. Close waits for the campaigns and renewals in flight to finish. It doesn't give up the lease,
+// which expires on its own; Resign first to hand it over at once. A candidate running WithCandidateRegistration is
+// deregistered before the pool is closed. Closing an election again is a no-op.
+func (e *Election) Close() error {
+	e.lifecycle.Lock()
+	defer e.lifecycle.Unlock()
+	if e.closed.Swap(true) {
+		return nil
+	}
+	if e.opts.registerCandidate && e.memory == nil {
+		// while the pool is still open: the election loop stopping with the election skips it
+		e.deregister(context.Background())
+	}
+	if !e.ownsDB {
+		return nil
+	}
+	sqlDB, err := e.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// checkOpen returns ErrElectionClosed once the election is closed.
+func (e *Election) checkOpen() error {
+	if e.closed.Load() {
+		return ErrElectionClosed
+	}
+	return nil
+}
+
+// resign gives up the lease like Resign, but leaves the candidate free to campaign again, for the election loop
+// stepping down on its own.
+func (e *Election) resign(ctx context.Context) error {
+	if e.memory != nil {
+		e.memory.resign(e)
+		return nil
+	}
+	started := time.Now()
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	sql := e.opts.sqlBuilder.Resign(e.recordsTable(), e.resignParams())
+	result := e.conn(ctx).Exec(e.writeSQL(sql), e.storedName, e.candidate)
+	err := ctxError(ctx, result.Error)
+	e.observeOperation(OperationResign, started, err)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected > 0 {
+		e.logEvent(ctx, slog.LevelInfo, "resigned leadership", OutcomeResigned)
+	}
+	return nil
+}
+
+// Reset clears the election's leader and lease whoever holds it, so the next campaign wins it afresh: an administrative
+// tool for development. Unlike Resign it doesn't check ownership, so it must not be used in production while
+// candidates are participating: a running leader would keep acting on a lease that no longer exists until it next
+// renews. The term is kept, so the next leader's term still exceeds every earlier one as a fencing token.
+func (e *Election) Reset(ctx context.Context) error {
+	ctx = orBackground(ctx)
+	if err := e.checkOpen(); err != nil {
+		return err
+	}
+	if e.memory != nil {
+		e.memory.reset(e)
+		return nil
+	}
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	sql := `UPDATE {records} SET leader_name = '', correlation_id = NULL, hold_until = NULL, ` +
+		e.resignParams().lapse() + ` WHERE election_name=?`
+	if err := e.conn(ctx).Exec(e.writeSQL(sql), e.storedName).Error; err != nil {
+		return ctxError(ctx, err)
+	}
+	e.logger.Warn("election reset")
+	return nil
+}
+
+// IsLeader reports whether this candidate holds a valid lease on the election, remembering the term it holds.
+func (e *Election) IsLeader(ctx context.Context) (bool, error) {
+	ctx = orBackground(ctx)
+	if err := e.checkOpen(); err != nil {
+		return false, err
+	}
+	if e.memory != nil {
+		return e.memory.isLeader(e), nil
+	}
+	started := time.Now()
+	held, err := e.isLeader(ctx)
+	e.observeOperation(OperationIsLeader, started, err)
+	return held, err
+}
+
+func (e *Election) isLeader(ctx context.Context) (bool, error) {
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	var term uint64
+	sql := e.opts.sqlBuilder.IsLeader(e.recordsTable(), e.leaseParams())
+	result := e.conn(ctx).Raw(e.readSQL(sql), e.storedName, e.candidate).Scan(&term)
+	if result.Error != nil {
+		return false, ctxError(ctx, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		held, err := e.aliasHeld(ctx)
+		return held, ctxError(ctx, err)
+	}
+	e.term.Store(term)
+	return true, nil
+}
+
+// TimeUntilExpiry returns how long the lease held by this candidate stays valid without renewal, computed on the
+// database server, or ErrLeaseLost if this candidate doesn't hold a valid lease. A leader can use it to decide whether
+// enough of its lease remains to safely start a chunk of work.
+func (e *Election) TimeUntilExpiry(ctx context.Context) (time.Duration, error) {
+	ctx = orBackground(ctx)
+	if err := e.checkOpen(); err != nil {
+		return 0, err
+	}
+	if e.memory != nil {
+		return e.memory.timeUntilExpiry(e)
+	}
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	var remaining int64
+	lease := e.leaseParams()
+	sql := `SELECT ` + lease.untilExpiry(0, 0) + ` FROM {records}
+			where election_name=? and ` + isCandidate + ` and ` + lease.held()
+	result := e.conn(ctx).Raw(e.readSQL(sql), e.storedName, e.candidate).Scan(&remaining)
+	if result.Error != nil {
+		return 0, ctxError(ctx, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return 0, ErrLeaseLost
+	}
+	return time.Duration(remaining) * time.Microsecond, nil
+}
+
+// GetLeader returns the name of the candidate holding a valid lease on the election, or ErrNoLeader if the election
+// has no live leader.
+func (e *Election) GetLeader(ctx context.Context) (string, error) {
+	ctx = orBackground(ctx)
+	if err := e.checkOpen(); err != nil {
+		return "", err
+	}
+	if e.memory != nil {
+		return e.memory.getLeader(e)
+	}
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	var leader string
+	sql := e.opts.sqlBuilder.Leader(e.recordsTable(), e.leaseParams())
+	result := e.conn(ctx).Raw(e.readSQL(sql), e.storedName).Scan(&leader)
+	if result.Error != nil {
+		return "", ctxError(ctx, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return "", ErrNoLeader
+	}
+	return leader, nil
+}
+
+// HasLeader reports whether some candidate holds a valid lease on the election. It is cheaper than GetLeader when the
+// leader's name doesn't matter, e.g. for a follower deciding whether to proceed or wait.
+func (e *Election) HasLeader(ctx context.Context) (bool, error) {
+	ctx = orBackground(ctx)
+	if err := e.checkOpen(); err != nil {
+		return false, err
+	}
+	if e.memory != nil {
+		_, err := e.memory.getLeader(e)
+		if errors.Is(err, ErrNoLeader) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	var exists bool
+	sql := `SELECT EXISTS(SELECT 1 FROM {records} where election_name=? and ` + e.leaseParams().held() + `)`
+	if err := e.conn(ctx).Raw(e.readSQL(sql), e.storedName).Scan(&exists).Error; err != nil {
+		return false, ctxError(ctx, err)
+	}
+	return exists, nil
+}
+
+// DBStats returns the statistics of the connection pool the election queries, e.g. to monitor whether the election
+// loop competes with application traffic for connections. Elections of a Manager share its pool, and in-memory
+// elections have none, reporting zero stats.
+func (e *Election) DBStats() sql.DBStats {
+	if e.db == nil {
+		return sql.DBStats{}
+	}
+	sqlDB, err := e.db.DB()
+	if err != nil {
+		return sql.DBStats{}
+	}
+	return sqlDB.Stats()
+}
+
+// resolveTables looks up the table names of the election models through the GORM naming strategy, so the raw SQL
+// queries the same tables AutoMigrate creates.
+func (e *Election) resolveTables() error {
+	var names []string
+	for placeholder, model := range map[string]interface{}{
+		"{records}":    &ElectionRecord{},
+		"{history}":    &HistoryEntry{},
+		"{candidates}": &CandidateEntry{},
+		"{stats}":      &StatsEntry{},
+	} {
+		stmt := &gorm.Statement{DB: e.db}
+		if err := stmt.Parse(model); err != nil {
+			return fmt.Errorf("failed to resolve table name: %w", err)
+		}
+		table := stmt.Schema.Table
+		if e.opts.schema != "" {
+			table = e.opts.schema + "." + table
 		}
+		names = append(names, placeholder, table)
 	}
-	return as, nil
+	e.tables = strings.NewReplacer(names...)
+	return nil
+}
+
+// sql fills the table names into a query written with {records} and {history} placeholders.
+func (e *Election) sql(query string) string {
+	return e.tables.Replace(query)
+}
+
+// recordsTable returns the name of the election table.
+func (e *Election) recordsTable() string {
+	return e.sql("{records}")
+}
+
+// writeSQL prepares a statement that must run on the primary, prefixed with the configured write hint.
+func (e *Election) writeSQL(query string) string {
+	return e.opts.writeHint + e.sql(query)
+}
+
+// readSQL prepares a read-only query, prefixed with the configured read hint.
+func (e *Election) readSQL(query string) string {
+	return e.opts.readHint + e.sql(query)
+}
+
+// migrateAttempts bounds how often a migration statement is run: retried after losing a race to create a table, once
+// for the table, its columns and its indexes, or after a transient failure on a busy database, such as a metadata
+// lock wait, backing off from migrateRetryDelay.
+const (
+	migrateAttempts   = 5
+	migrateRetryDelay = 250 * time.Millisecond
+)
+
+// tableModel is a table the election uses, by its placeholder, with the model it is migrated from.
+type tableModel struct {
+	placeholder string
+	model       interface{}
+}
+
+// tableModels are the tables the election's options make it use.
+func (e *Election) tableModels() []tableModel {
+	tables := []tableModel{{"{records}", &ElectionRecord{}}}
+	if e.opts.history {
+		tables = append(tables, tableModel{"{history}", &HistoryEntry{}})
+	}
+	if e.opts.registerCandidate {
+		tables = append(tables, tableModel{"{candidates}", &CandidateEntry{}})
+	}
+	if e.opts.stats {
+		tables = append(tables, tableModel{"{stats}", &StatsEntry{}})
+	}
+	return tables
+}
+
+// tableDB returns e.db bound to ctx and to the table behind placeholder, so migrations honour WithSchema.
+func (e *Election) tableDB(ctx context.Context, placeholder string) *gorm.DB {
+	return e.db.WithContext(ctx).Table(e.sql(placeholder))
+}
+
+// migrate creates or updates the tables the election uses. It tolerates candidates starting together and migrating
+// concurrently: whatever another migration created first is taken as is.
+func (e *Election) migrate(ctx context.Context) error {
+	if e.opts.createOnlyMigrate {
+		if err := e.createMissing(ctx, e.tableModels()); err != nil {
+			return err
+		}
+		return e.verifyPrecision(ctx, false)
+	}
+	for _, table := range e.tableModels() {
+		err := e.retryMigration(ctx, func() error {
+			return e.tableDB(ctx, table.placeholder).AutoMigrate(table.model)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create/update db tables with error %s", err.Error())
+		}
+	}
+	return e.verifyPrecision(ctx, true)
+}
+
+// retryMigration runs the migration statements of migrate until they succeed, up to migrateAttempts times. Only
+// failures that can resolve themselves are retried: losing a race to a concurrent migration, which is retried
+// against what it created, and errors the retry classifier considers transient, such as lock wait timeouts, after
+// backing off. Anything else, such as a permission error, is returned straight away.
+func (e *Election) retryMigration(ctx context.Context, migrate func() error) error {
+	err := migrate()
+	for attempt := 1; err != nil && attempt < migrateAttempts; attempt++ {
+		switch {
+		case alreadyExists(err):
+			e.logger.Debug("election table changed concurrently, migrating again", slog.Any("error", err))
+		case e.opts.isRetryable(err):
+			delay := migrateRetryDelay << (attempt - 1)
+			e.logger.Warn("election table migration failed, retrying", slog.Int("attempt", attempt),
+				slog.Duration("delay", delay), slog.Any("error", err))
+			if sleepErr := sleep(ctx, delay); sleepErr != nil {
+				return err
+			}
+		default:
+			return err
+		}
+		err = migrate()
+	}
+	return err
+}
+
+// recoverMissingTable handles err being MySQL's "table doesn't exist", e.g. after the table was dropped from under a
+// running election. With auto-migration enabled the tables are recreated and the caller should retry once; otherwise
+// the error is wrapped with ErrTableMissing.
+func (e *Election) recoverMissingTable(ctx context.Context, err error) (bool, error) {
+	var mysqlErr *mysqldriver.MySQLError
+	if !errors.As(err, &mysqlErr) || mysqlErr.Number != errNoSuchTable {
+		return false, err
+	}
+	if !e.opts.autoMigrate {
+		return false, fmt.Errorf("%w: %w", ErrTableMissing, err)
+	}
+	e.logEvent(ctx, slog.LevelWarn, "election table is missing, recreating it", OutcomeError, slog.Any("error", err))
+	if migrateErr := e.migrate(ctx); migrateErr != nil {
+		return false, fmt.Errorf("%w: %w", ErrTableMissing, migrateErr)
+	}
+	return true, nil
+}
+
+// conn returns the handle election queries run on, bound to ctx so they honour its deadline and cancellation: the
+// connection reserved by reserveConn if there is one, otherwise the shared pool.
+func (e *Election) conn(ctx context.Context) *gorm.DB {
+	if reserved := e.reserved.Load(); reserved != nil {
+		return reserved.WithContext(ctx)
+	}
+	return e.db.WithContext(ctx)
+}
+
+// reservedConn is the single connection reserveConn pins election queries to.
+type reservedConn struct {
+	e    *Election
+	conn *sql.Conn
+}
+
+// reserveConn pins election queries to a single connection taken from the pool until the returned connection is
+// released.
+func (e *Election) reserveConn(ctx context.Context) (*reservedConn, error) {
+	r := &reservedConn{e: e}
+	if err := r.reset(ctx); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// reset replaces the reserved connection with a fresh one from the pool, as a connection that was killed or dropped
+// by the server can't be used again, unlike the pool's, which are replaced as they fail.
+func (r *reservedConn) reset(ctx context.Context) error {
+	sqlDB, err := r.e.db.DB()
+	if err != nil {
+		return err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to reserve a connection: %w", err)
+	}
+	reserved := r.e.db.Session(&gorm.Session{Context: ctx})
+	reserved.Statement.ConnPool = conn
+	r.e.reserved.Store(reserved)
+	if r.conn != nil {
+		_ = r.conn.Close()
+	}
+	r.conn = conn
+	return nil
+}
+
+// release returns the reserved connection to the pool, and election queries to the shared pool.
+func (r *reservedConn) release() {
+	r.e.reserved.Store(nil)
+	_ = r.conn.Close()
+}
+
+// orBackground returns ctx, or context.Background() if ctx is nil, so the public methods are forgiving of a nil
+// context rather than panicking deep in GORM.
+func orBackground(ctx context.Context) context.Context {
+	if ctx == nil {
+		return context.Background()
+	}
+	return ctx
+}
+
+// ctxError makes sure a query that failed while ctx was done reports the context error, so callers can tell it from
+// a database failure: the driver may report an aborted query as a broken connection instead.
+func ctxError(ctx context.Context, err error) error {
+	if err == nil || ctx.Err() == nil || errors.Is(err, ctx.Err()) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ctx.Err(), err)
+}
+
+// queryContext bounds a single election query by the query timeout, on top of any deadline ctx already carries.
+func (e *Election) queryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, e.opts.queryTimeout)
+}
+
+func (e *Election) leaseSeconds() int64 {
+	return int64(e.opts.leaseDuration / time.Second)
+}
+
+func (e *Election) leaseParams() LeaseParams {
+	return e.leaseParamsWithSkew(e.opts.skewTolerance + e.expiryJitter())
+}
+
+// resignParams are the lease parameters Resign back-dates the lease by: past the largest expiry jitter any candidate
+// may wait, rather than this one's.
+func (e *Election) resignParams() LeaseParams {
+	return e.leaseParamsWithSkew(e.opts.skewTolerance + e.opts.expiryJitter)
+}
+
+func (e *Election) leaseParamsWithSkew(skew time.Duration) LeaseParams {
+	return LeaseParams{
+		Seconds:        e.leaseSeconds(),
+		MinHoldSeconds: int64(e.opts.minHold / time.Second),
+		// rounded up, so the tolerance is never cut short
+		SkewSeconds:   int64((skew + time.Second - 1) / time.Second),
+		Microseconds:  e.opts.microseconds,
+		LeaseMicros:   e.opts.leaseDuration.Microseconds(),
+		MinHoldMicros: e.opts.minHold.Microseconds(),
+		SkewMicros:    skew.Microseconds(),
+		Epoch:         e.opts.epochLease,
+	}
+}
+
+// processJitter is the share of WithExpiryJitter's limit this process waits, drawn once so all its elections with
+// the same limit share their lease parameters, and so their batched renewals.
+var processJitter = sync.OnceValue(func() float64 {
+	return rand.Float64()
+})
+
+// expiryJitter is the extra time this process waits past an unrenewed lease before taking it over, see
+// WithExpiryJitter.
+func (e *Election) expiryJitter() time.Duration {
+	if e.opts.expiryJitter <= 0 {
+		return 0
+	}
+	return time.Duration(processJitter() * float64(e.opts.expiryJitter))
+}
+
+// StoredElectionName returns the name the election is stored under in the database: the election name itself, or for
+// names hashed by WithLongNameHashing, a readable prefix of it followed by a hash of the full name.
+func (e *Election) StoredElectionName() string {
+	return e.storedName
+}
+
+// hashedElectionName shortens name to fit the election_name column, keeping as much of it as fits as a readable
+// prefix and making it collision resistant with a SHA-256 of the full name.
+func hashedElectionName(name string) string {
+	sum := sha256.Sum256([]byte(name))
+	suffix := "#" + hex.EncodeToString(sum[:])
+	n := maxElectionNameLength - len(suffix)
+	for n > 0 && !utf8.RuneStart(name[n]) {
+		n--
+	}
+	return name[:n] + suffix
+}
+
+// backendID identifies the database server the election's queries run on.
+func (e *Election) backendID(ctx context.Context) (string, error) {
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	var id string
+	err := e.conn(ctx).Raw(e.writeSQL(`SELECT CONCAT(@@server_id, '/', @@server_uuid)`)).Scan(&id).Error
+	return id, err
+}
+
+// Term returns the leadership term last observed by IsLeader, or zero if this candidate has not led yet.
+func (e *Election) Term() uint64 {
+	return e.term.Load()
 }
@@ -0,0 +1,591 @@
+package leaderelection
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newUnreachableElection returns an election whose pool points at a port nothing listens on, so every query fails
+// unless it is stopped before reaching the network.
+func newUnreachableElection(t *testing.T, opts ...Option) *Election {
+	t.Helper()
+	e, err := newElection("test", "candidate", opts)
+	if err != nil {
+		t.Fatalf("newElection: %v", err)
+	}
+	e.db, err = gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "user:password@tcp(127.0.0.1:1)/test?parseTime=true&timeout=1s",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DisableAutomaticPing: true, Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("gorm.Open: %v", err)
+	}
+	e.ownsDB = true
+	if err = e.resolveTables(); err != nil {
+		t.Fatalf("resolveTables: %v", err)
+	}
+	t.Cleanup(func() { _ = e.Close() })
+	return e
+}
+
+func TestMethodsHonourCancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for name, call := range map[string]func(e *Election) error{
+		"Campaign": func(e *Election) error { _, err := e.Campaign(ctx); return err },
+		"CampaignOrFollow": func(e *Election) error {
+			_, _, err := e.CampaignOrFollow(ctx)
+			return err
+		},
+		"Renew":              func(e *Election) error { _, err := e.Renew(ctx); return err },
+		"RenewAndVerifyTerm": func(e *Election) error { return e.RenewAndVerifyTerm(ctx, 1) },
+		"Resign":             func(e *Election) error { return e.Resign(ctx) },
+		"Reset":              func(e *Election) error { return e.Reset(ctx) },
+		"IsLeader":           func(e *Election) error { _, err := e.IsLeader(ctx); return err },
+		"TimeUntilExpiry":    func(e *Election) error { _, err := e.TimeUntilExpiry(ctx); return err },
+		"GetLeader":          func(e *Election) error { _, err := e.GetLeader(ctx); return err },
+		"HasLeader":          func(e *Election) error { _, err := e.HasLeader(ctx); return err },
+		"GetLeaderInfo":      func(e *Election) error { _, err := e.GetLeaderInfo(ctx); return err },
+		"Candidates":         func(e *Election) error { _, err := e.Candidates(ctx); return err },
+		"History":            func(e *Election) error { _, err := e.History(ctx, 10); return err },
+		"ExplainAcquire":     func(e *Election) error { _, err := e.ExplainAcquire(ctx); return err },
+		"ElectionStats":      func(e *Election) error { _, err := e.ElectionStats(ctx); return err },
+		"Repair":             func(e *Election) error { _, err := e.Repair(ctx, true); return err },
+		"WaitReady":          func(e *Election) error { return e.WaitReady(ctx) },
+		"WaitForLeader":      func(e *Election) error { _, err := e.WaitForLeader(ctx); return err },
+	} {
+		t.Run(name, func(t *testing.T) {
+			e := newUnreachableElection(t)
+			started := time.Now()
+			err := call(e)
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("%s with a cancelled context = %v, want context.Canceled", name, err)
+			}
+			if took := time.Since(started); took > 500*time.Millisecond {
+				t.Errorf("%s with a cancelled context took %s, want it to return promptly", name, took)
+			}
+		})
+	}
+}
+
+func TestIsLeaderCachedDoesNotAllocate(t *testing.T) {
+	e, err := NewMemoryElection(nil, "test", "candidate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.leading.Store(true)
+	if allocs := testing.AllocsPerRun(1000, func() { _ = e.IsLeaderCached() }); allocs != 0 {
+		t.Fatalf("IsLeaderCached allocates %g times per call, want 0", allocs)
+	}
+}
+
+func BenchmarkIsLeaderCached(b *testing.B) {
+	e, err := NewMemoryElection(nil, "test", "candidate")
+	if err != nil {
+		b.Fatal(err)
+	}
+	e.leading.Store(true)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if !e.IsLeaderCached() {
+				b.Fatal("IsLeaderCached() = false, want true")
+			}
+		}
+	})
+}
+
+func TestIsLeaderCachedFollowsTransitions(t *testing.T) {
+	e := newMemoryCandidate(t, NewMemoryRegistry(), "candidate")
+	ctx, cancel := context.WithCancel(context.Background())
+	elected := make(chan struct{})
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- e.Run(ctx, func() { close(elected) }, func() {})
+	}()
+	select {
+	case <-elected:
+	case <-time.After(time.Second):
+		t.Fatal("the candidate wasn't elected")
+	}
+	if !e.IsLeaderCached() {
+		t.Fatal("IsLeaderCached() = false after winning the election, want true")
+	}
+	cancel()
+	if err := <-stopped; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run() = %v, want context.Canceled", err)
+	}
+	if e.IsLeaderCached() {
+		t.Fatal("IsLeaderCached() = true after the election stopped, want false")
+	}
+}
+
+// leaseHolder answers the queries of a candidate holding the lease under term 1.
+func leaseHolder(candidate string) func(query fakeQuery) (*fakeResult, error) {
+	return func(query fakeQuery) (*fakeResult, error) {
+		switch {
+		case strings.HasPrefix(query.sql, "INSERT INTO election_records"):
+			return &fakeResult{rowsAffected: 2}, nil
+		case strings.HasPrefix(query.sql, "SELECT leader_name, "):
+			return row([]string{"leader_name", "term", "held"}, candidate, int64(1), true), nil
+		case strings.HasPrefix(query.sql, "SELECT term FROM"):
+			return row([]string{"term"}, int64(1)), nil
+		case strings.HasPrefix(query.sql, "UPDATE election_records SET"):
+			return &fakeResult{rowsAffected: 1}, nil
+		}
+		return nil, nil
+	}
+}
+
+func TestTinyDeadlinesSurfaceContextErrors(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+	e := newFakeElection(t, &fakeDB{handle: leaseHolder("candidate")})
+	if won, err := e.Campaign(ctx); won || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Campaign() = %v, %v, want false, context.DeadlineExceeded", won, err)
+	}
+	if won, _, err := e.CampaignOrFollow(ctx); won || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("CampaignOrFollow() = %v, %v, want false, context.DeadlineExceeded", won, err)
+	}
+	if renewed, err := e.Renew(ctx); renewed || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Renew() = %v, %v, want false, context.DeadlineExceeded", renewed, err)
+	}
+	if isLeader, err := e.IsLeader(ctx); isLeader || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("IsLeader() = %v, %v, want false, context.DeadlineExceeded", isLeader, err)
+	}
+	if err := e.RenewAndVerifyTerm(ctx, 1); errors.Is(err, ErrLeaseLost) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("RenewAndVerifyTerm() = %v, want context.DeadlineExceeded rather than ErrLeaseLost", err)
+	}
+}
+
+// TestRenewalTimeoutKeepsLeadership checks that a renewal cut short by the query timeout is retried as a transient
+// failure, rather than counted as losing the lease.
+func TestRenewalTimeoutKeepsLeadership(t *testing.T) {
+	holder := leaseHolder("candidate")
+	var campaigns atomic.Int32
+	db := &fakeDB{handle: func(query fakeQuery) (*fakeResult, error) {
+		if strings.HasPrefix(query.sql, "INSERT INTO election_records") && campaigns.Add(1) == 2 {
+			// the first renewal outlasts the query timeout
+			<-query.ctx.Done()
+			return nil, query.ctx.Err()
+		}
+		return holder(query)
+	}}
+	e := newFakeElection(t, db, shortLease...)
+	ctx, cancel := context.WithCancel(context.Background())
+	var lost atomic.Int32
+	elected := make(chan struct{})
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- e.Run(ctx, func() { close(elected) }, func() { lost.Add(1) })
+	}()
+	select {
+	case <-elected:
+	case <-time.After(time.Second):
+		t.Fatal("the candidate wasn't elected")
+	}
+	deadline := time.Now().Add(time.Second)
+	for campaigns.Load() < 4 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if n := campaigns.Load(); n < 4 {
+		t.Fatalf("%d campaigns ran, want renewals to go on after the one that timed out", n)
+	}
+	if !e.IsLeaderCached() || lost.Load() != 0 {
+		t.Fatalf("IsLeaderCached() = %v with %d losses, want leadership kept through a timed out renewal",
+			e.IsLeaderCached(), lost.Load())
+	}
+	cancel()
+	<-stopped
+}
+
+// TestBlockedRenewalKeepsLeadership checks that a leader whose renewal queues behind other campaigns on the row lock
+// counts it as a failed renewal, rather than giving up the lease it still holds.
+func TestBlockedRenewalKeepsLeadership(t *testing.T) {
+	for name, tc := range map[string]struct {
+		opts    []Option
+		blocked func(query fakeQuery) error
+	}{
+		"acquire timeout": {
+			opts: []Option{WithAcquireTimeout(2 * time.Millisecond)},
+			blocked: func(query fakeQuery) error {
+				<-query.ctx.Done()
+				return query.ctx.Err()
+			},
+		},
+		"lock wait timeout": {
+			blocked: func(fakeQuery) error {
+				return &mysqldriver.MySQLError{Number: errLockWaitTimeout, Message: "Lock wait timeout exceeded"}
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			holder := leaseHolder("candidate")
+			var campaigns atomic.Int32
+			db := &fakeDB{handle: func(query fakeQuery) (*fakeResult, error) {
+				if strings.HasPrefix(query.sql, "INSERT INTO election_records") && campaigns.Add(1) == 2 {
+					return nil, tc.blocked(query)
+				}
+				return holder(query)
+			}}
+			e := newFakeElection(t, db, append(slices.Clone(shortLease), tc.opts...)...)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			var lost atomic.Int32
+			elected := make(chan struct{})
+			stopped := make(chan error, 1)
+			go func() {
+				stopped <- e.Run(ctx, func() { close(elected) }, func() { lost.Add(1) })
+			}()
+			select {
+			case <-elected:
+			case <-time.After(time.Second):
+				t.Fatal("the candidate wasn't elected")
+			}
+			deadline := time.Now().Add(time.Second)
+			for campaigns.Load() < 4 && time.Now().Before(deadline) {
+				time.Sleep(5 * time.Millisecond)
+			}
+			if n := campaigns.Load(); n < 4 {
+				t.Fatalf("%d campaigns ran, want renewals to go on after the blocked one", n)
+			}
+			if !e.IsLeaderCached() || lost.Load() != 0 {
+				t.Fatalf("IsLeaderCached() = %v with %d losses, want leadership kept through a blocked renewal",
+					e.IsLeaderCached(), lost.Load())
+			}
+			cancel()
+			<-stopped
+		})
+	}
+}
+
+// TestConcurrentFirstCampaigns races the first campaigns of many candidates on the MySQL path, against an election
+// row whose upsert inserts it for the first of them and leaves it to that one.
+func TestConcurrentFirstCampaigns(t *testing.T) {
+	const candidates = 20
+	for name, opts := range map[string][]Option{
+		"read back": nil,
+		"fast path": {WithRowsAffectedFastPath()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			lease := &fakeLease{}
+			db := &fakeDB{handle: lease.handle}
+			elections := make([]*Election, candidates)
+			for i := range elections {
+				elections[i] = newFakeCandidate(t, db, fmt.Sprintf("candidate-%d", i), opts...)
+			}
+			var wg sync.WaitGroup
+			won := make([]bool, candidates)
+			errs := make([]error, candidates)
+			start := make(chan struct{})
+			for i, e := range elections {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					<-start
+					won[i], errs[i] = e.Campaign(context.Background())
+				}()
+			}
+			close(start)
+			wg.Wait()
+			if err := errors.Join(errs...); err != nil {
+				t.Fatalf("concurrent first campaigns failed: %v", err)
+			}
+			var winners []string
+			for i, e := range elections {
+				if won[i] {
+					winners = append(winners, e.candidate)
+				}
+			}
+			if len(winners) != 1 || winners[0] != lease.leader {
+				t.Fatalf("winners = %v with %q on the row, want exactly the candidate on the row", winners, lease.leader)
+			}
+		})
+	}
+}
+
+// TestCandidateNamesAreCaseSensitive checks that candidates whose names differ only by case are told apart by every
+// ownership check, against an election row compared under a case-insensitive collation.
+func TestCandidateNamesAreCaseSensitive(t *testing.T) {
+	for name, opts := range map[string][]Option{
+		"read back": nil,
+		"fast path": {WithRowsAffectedFastPath()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			lease := &fakeLease{}
+			db := &fakeDB{handle: lease.handle}
+			upper := newFakeCandidate(t, db, "Worker/A", opts...)
+			lower := newFakeCandidate(t, db, "worker/a", opts...)
+			if won, err := upper.Campaign(ctx); err != nil || !won {
+				t.Fatalf("Worker/A Campaign() = %v, %v, want true", won, err)
+			}
+			if won, err := lower.Campaign(ctx); err != nil || won {
+				t.Errorf("worker/a Campaign() = %v, %v, want false while Worker/A leads", won, err)
+			}
+			if isLeader, err := lower.IsLeader(ctx); err != nil || isLeader {
+				t.Errorf("worker/a IsLeader() = %v, %v, want false while Worker/A leads", isLeader, err)
+			}
+			if renewed, err := lower.Renew(ctx); err != nil || renewed {
+				t.Errorf("worker/a Renew() = %v, %v, want false while Worker/A leads", renewed, err)
+			}
+			if err := lower.RenewAndVerifyTerm(ctx, 1); !errors.Is(err, ErrLeaseLost) {
+				t.Errorf("worker/a RenewAndVerifyTerm() = %v, want ErrLeaseLost while Worker/A leads", err)
+			}
+			if err := lower.Resign(ctx); err != nil {
+				t.Fatalf("worker/a Resign() = %v", err)
+			}
+			if isLeader, err := upper.IsLeader(ctx); err != nil || !isLeader {
+				t.Errorf("Worker/A IsLeader() = %v, %v, want true after worker/a resigned", isLeader, err)
+			}
+			for _, query := range db.received() {
+				if slices.ContainsFunc(query.args, func(arg driver.NamedValue) bool { return arg.Value == "worker/a" }) &&
+					!strings.Contains(query.sql, "AS BINARY)") {
+					t.Errorf("worker/a's candidate name isn't compared as binary:\n%s", query.sql)
+				}
+			}
+		})
+	}
+}
+
+func TestCampaignOutcome(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		opts         []Option
+		rowsAffected int64
+		leader       string
+		held         bool
+		want         bool
+		wantReadBack bool
+	}{
+		{name: "insert", rowsAffected: 1, leader: "candidate", held: true, want: true, wantReadBack: true},
+		{name: "self-renew or steal", rowsAffected: 2, leader: "candidate", held: true, want: true, wantReadBack: true},
+		{name: "self-renew unchanged", rowsAffected: 0, leader: "candidate", held: true, want: true, wantReadBack: true},
+		{name: "steal refused", rowsAffected: 0, leader: "other", held: true, want: false, wantReadBack: true},
+		{name: "lease lapsed", rowsAffected: 0, leader: "candidate", held: false, want: false, wantReadBack: true},
+		{name: "misreported count", rowsAffected: 2, leader: "other", held: true, want: false, wantReadBack: true},
+		{name: "fast path insert", opts: []Option{WithRowsAffectedFastPath()}, rowsAffected: 1, leader: "candidate",
+			held: true, want: true, wantReadBack: true},
+		{name: "fast path update", opts: []Option{WithRowsAffectedFastPath()}, rowsAffected: 2, want: true},
+		{name: "fast path unchanged", opts: []Option{WithRowsAffectedFastPath()}, rowsAffected: 0, want: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			db := &fakeDB{handle: func(query fakeQuery) (*fakeResult, error) {
+				switch {
+				case strings.HasPrefix(query.sql, "INSERT INTO election_records"):
+					return &fakeResult{rowsAffected: tc.rowsAffected}, nil
+				case strings.HasPrefix(query.sql, "SELECT leader_name, "):
+					return row([]string{"leader_name", "held"}, tc.leader, tc.held), nil
+				}
+				return nil, nil
+			}}
+			e := newFakeElection(t, db, tc.opts...)
+			won, err := e.Campaign(context.Background())
+			if err != nil || won != tc.want {
+				t.Fatalf("Campaign() = %v, %v, want %v", won, err, tc.want)
+			}
+			readBack := false
+			var conns []int
+			for _, query := range db.received() {
+				conns = append(conns, query.conn)
+				readBack = readBack || strings.HasPrefix(query.sql, "SELECT leader_name, ")
+			}
+			if readBack != tc.wantReadBack {
+				t.Fatalf("the lease was read back: %v, want %v", readBack, tc.wantReadBack)
+			}
+			if readBack && conns[0] != conns[1] {
+				t.Fatalf("the lease was read back on connection %d, after the upsert ran on %d", conns[1], conns[0])
+			}
+		})
+	}
+}
+
+// TestKilledDedicatedConnectionIsReplaced kills the dedicated connection of a leader, as KILL on the server would, and
+// checks that the election carries on over a fresh connection without a restart.
+func TestKilledDedicatedConnectionIsReplaced(t *testing.T) {
+	db := &fakeDB{handle: leaseHolder("candidate")}
+	e := newFakeElection(t, db, append(shortLease, WithDedicatedConn())...)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	elected := make(chan struct{})
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- e.Run(ctx, func() { close(elected) }, func() {})
+	}()
+	select {
+	case <-elected:
+	case <-time.After(time.Second):
+		t.Fatal("the candidate wasn't elected")
+	}
+	queries := db.received()
+	killed := queries[len(queries)-1].conn
+	db.kill(killed)
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		queries = db.received()
+		if last := queries[len(queries)-1]; last.conn != killed && strings.HasPrefix(last.sql, "SELECT term FROM") {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if last := queries[len(queries)-1]; last.conn == killed {
+		t.Fatalf("the election still queries connection %d after it was killed", killed)
+	}
+	if !e.IsLeaderCached() {
+		t.Fatal("IsLeaderCached() = false, want the lease renewed over the fresh connection")
+	}
+	cancel()
+	if err := <-stopped; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run() = %v, want context.Canceled", err)
+	}
+}
+
+// closedElectionCalls calls every method of e that uses the database, returning their errors by name.
+func closedElectionCalls(e *Election) map[string]error {
+	ctx := context.Background()
+	nop := func(context.Context) error { return nil }
+	errs := make(map[string]error)
+	_, errs["Campaign"] = e.Campaign(ctx)
+	_, errs["CampaignTx"] = e.CampaignTx(ctx, nil)
+	_, _, errs["CampaignOrFollow"] = e.CampaignOrFollow(ctx)
+	errs["Bootstrap"] = e.Bootstrap(ctx)
+	_, errs["Renew"] = e.Renew(ctx)
+	errs["RenewAndVerifyTerm"] = e.RenewAndVerifyTerm(ctx, 1)
+	errs["Resign"] = e.Resign(ctx)
+	errs["Reset"] = e.Reset(ctx)
+	_, errs["IsLeader"] = e.IsLeader(ctx)
+	_, errs["TimeUntilExpiry"] = e.TimeUntilExpiry(ctx)
+	_, errs["GetLeader"] = e.GetLeader(ctx)
+	_, errs["HasLeader"] = e.HasLeader(ctx)
+	_, errs["GetLeaderInfo"] = e.GetLeaderInfo(ctx)
+	_, errs["Candidates"] = e.Candidates(ctx)
+	_, errs["History"] = e.History(ctx, 10)
+	_, errs["WatchTransitions"] = e.WatchTransitions(ctx)
+	_, errs["ExplainAcquire"] = e.ExplainAcquire(ctx)
+	_, errs["ElectionStats"] = e.ElectionStats(ctx)
+	_, errs["Repair"] = e.Repair(ctx, true)
+	errs["WaitReady"] = e.WaitReady(ctx)
+	_, errs["WaitForLeader"] = e.WaitForLeader(ctx)
+	errs["WaitForLeadership"] = e.WaitForLeadership(ctx)
+	errs["Run"] = e.Run(ctx, func() {}, func() {})
+	errs["RunLeaderTask"] = e.RunLeaderTask(ctx, time.Second, nop)
+	errs["DoIfLeader"] = e.DoIfLeader(ctx, nop)
+	errs["WithLeadership"] = e.WithLeadership(ctx, nop)
+	return errs
+}
+
+func TestClosedElection(t *testing.T) {
+	for backend, e := range map[string]*Election{
+		"mysql":  newFakeElection(t, &fakeDB{handle: leaseHolder("candidate")}),
+		"memory": newMemoryCandidate(t, NewMemoryRegistry(), "candidate"),
+	} {
+		t.Run(backend, func(t *testing.T) {
+			if err := e.Close(); err != nil {
+				t.Fatalf("Close() = %v", err)
+			}
+			if err := e.Close(); err != nil {
+				t.Fatalf("Close() again = %v, want closing to be idempotent", err)
+			}
+			for method, err := range closedElectionCalls(e) {
+				if !errors.Is(err, ErrElectionClosed) {
+					t.Errorf("%s after Close = %v, want ErrElectionClosed", method, err)
+				}
+			}
+		})
+	}
+}
+
+// TestCloseDeregistersCandidate checks that closing a running election deregisters its candidate while the pool is
+// still open, rather than the stopping election loop failing to on the closed pool.
+func TestCloseDeregistersCandidate(t *testing.T) {
+	var logs bytes.Buffer
+	db := &fakeDB{handle: leaseHolder("candidate")}
+	e := newFakeElection(t, db, append(shortLease, WithCandidateRegistration(),
+		WithLogger(slog.New(slog.NewTextHandler(&logs, nil))))...)
+	stopped := make(chan error, 1)
+	go func() { stopped <- e.Run(context.Background(), func() {}, func() {}) }()
+	deadline := time.Now().Add(time.Second)
+	for !slices.ContainsFunc(db.received(), func(q fakeQuery) bool {
+		return strings.HasPrefix(q.sql, "INSERT INTO election_candidates")
+	}) {
+		if time.Now().After(deadline) {
+			t.Fatal("the election loop didn't register its candidate")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := e.Close(); err != nil {
+		t.Fatalf("Close() = %v", err)
+	}
+	select {
+	case err := <-stopped:
+		if !errors.Is(err, ErrElectionClosed) {
+			t.Fatalf("Run() = %v, want ErrElectionClosed", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the election loop didn't stop with Close")
+	}
+	if strings.Contains(logs.String(), "failed to deregister candidate") {
+		t.Fatalf("deregistering failed:\n%s", logs.String())
+	}
+	var deregistered int
+	for _, query := range db.received() {
+		if strings.HasPrefix(query.sql, "DELETE FROM election_candidates") {
+			deregistered++
+		}
+	}
+	if deregistered != 1 {
+		t.Fatalf("the candidate was deregistered %d times, want once", deregistered)
+	}
+}
+
+func TestCloseClosesOwnPool(t *testing.T) {
+	e := newFakeElection(t, &fakeDB{})
+	sqlDB, err := e.db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Close(); err != nil {
+		t.Fatalf("Close() = %v", err)
+	}
+	if err := sqlDB.Ping(); err == nil {
+		t.Fatal("the election's pool still answers after Close")
+	}
+}
+
+// TestResetKeepsTerm checks that Reset clears the lease in place rather than deleting the row, which would restart
+// terms from 1.
+func TestResetKeepsTerm(t *testing.T) {
+	db := &fakeDB{}
+	e := newFakeElection(t, db)
+	if err := e.Reset(context.Background()); err != nil {
+		t.Fatalf("Reset() = %v", err)
+	}
+	queries := db.received()
+	if len(queries) != 1 {
+		t.Fatalf("Reset sent %d statements, want 1", len(queries))
+	}
+	sql := queries[0].sql
+	if !strings.HasPrefix(sql, "UPDATE ") || strings.Contains(sql, "term") {
+		t.Fatalf("Reset doesn't clear the lease keeping the term:\n%s", sql)
+	}
+	if lapse := e.resignParams().lapse(); !strings.Contains(sql, lapse) {
+		t.Fatalf("Reset doesn't back-date the lease with %q:\n%s", lapse, sql)
+	}
+}
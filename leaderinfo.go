@@ -0,0 +1,67 @@
+package leaderelection
+
+import (
+	"context"
+	"log/slog"
+)
+
+// LeaderInfo describes the candidate holding the lease on an election. It marshals to JSON with the field names given
+// in its tags.
+type LeaderInfo struct {
+	Leader string `json:"leader"`
+	Term   uint64 `json:"term"`
+	// CorrelationID is the correlation ID the leader stamped on the election when it acquired the lease, see
+	// WithCorrelationID. It is omitted from JSON when empty.
+	CorrelationID string `json:"correlation_id,omitempty"`
+}
+
+// GetLeaderInfo returns the candidate holding a valid lease on the election, with its term and correlation ID, or
+// ErrNoLeader if the election has no live leader.
+func (e *Election) GetLeaderInfo(ctx context.Context) (LeaderInfo, error) {
+	ctx = orBackground(ctx)
+	if err := e.checkOpen(); err != nil {
+		return LeaderInfo{}, err
+	}
+	if e.memory != nil {
+		return e.memory.leaderInfo(e)
+	}
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	var info struct {
+		LeaderName    string
+		Term          uint64
+		CorrelationID string
+	}
+	sql := `SELECT leader_name, term, COALESCE(correlation_id, '') AS correlation_id FROM {records}
+			where election_name=? and ` + e.leaseParams().held()
+	result := e.conn(ctx).Raw(e.readSQL(sql), e.storedName).Scan(&info)
+	if result.Error != nil {
+		return LeaderInfo{}, ctxError(ctx, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return LeaderInfo{}, ErrNoLeader
+	}
+	return LeaderInfo{Leader: info.LeaderName, Term: info.Term, CorrelationID: info.CorrelationID}, nil
+}
+
+// stampCorrelation records the correlation ID of the acquisition of term on the election, if WithCorrelationID is
+// set. Failures are only logged: the ID is informational and must not hold up the election.
+func (e *Election) stampCorrelation(ctx context.Context, term uint64) {
+	if e.opts.correlationID == nil {
+		return
+	}
+	id := e.opts.correlationID(ctx)
+	if id == "" {
+		return
+	}
+	if e.memory != nil {
+		e.memory.stampCorrelation(e, term, id)
+		return
+	}
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	sql := `UPDATE {records} SET correlation_id = ? WHERE election_name=? and ` + isCandidate + ` and term=?`
+	if err := e.conn(ctx).Exec(e.writeSQL(sql), id, e.storedName, e.candidate, term).Error; err != nil {
+		e.logEvent(ctx, slog.LevelWarn, "failed to record the correlation ID", OutcomeError, slog.Any("error", err))
+	}
+}
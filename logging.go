@@ -0,0 +1,20 @@
+package leaderelection
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Attribute keys attached to every lifecycle record, so election events can be correlated with other structured logs.
+const (
+	LogKeyElection  = "election"
+	LogKeyCandidate = "candidate"
+	LogKeyTerm      = "term"
+	LogKeyOutcome   = "outcome"
+	LogKeyReason    = "reason"
+)
+
+func (e *Election) logEvent(ctx context.Context, level slog.Level, msg string, outcome Outcome, args ...any) {
+	args = append([]any{slog.Uint64(LogKeyTerm, e.Term()), slog.String(LogKeyOutcome, string(outcome))}, args...)
+	e.logger.Log(ctx, level, msg, args...)
+}
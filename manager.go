@@ -0,0 +1,192 @@
+package leaderelection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Manager participates in many elections as one candidate over a single shared connection pool, instead of one pool
+// per election. The elections remain independent in the database.
+type Manager struct {
+	candidate string
+	db        *gorm.DB
+	opts      []Option
+	o         options
+	// multiStatements is Election.multiStatements for the Manager's DSN.
+	multiStatements bool
+	redactedDSN     string
+	closed          atomic.Bool
+}
+
+// ElectionSpec describes one of the elections a Manager runs.
+type ElectionSpec struct {
+	Name string
+	// OnBecomeLeader and OnLoseLeadership are the election's callbacks, as for RunElection. Either may be nil.
+	OnBecomeLeader   CallbackFunc
+	OnLoseLeadership CallbackFunc
+	// Options are applied after the Manager's own options.
+	Options []Option
+}
+
+// NewManager connects to the database described by config, like NewElection, for the elections candidate will
+// participate in. The options apply to every election of the Manager; the connection pool is configured from them, so
+// WithNamingStrategy can't be overridden per election.
+func NewManager(candidate string, config map[string]string, opts ...Option) (*Manager, error) {
+	return NewManagerWithDSN(candidate, configDSN(config), opts...)
+}
+
+// NewManagerWithDSN is NewManager for a fully-formed go-sql-driver/mysql DSN. The DSN must enable parseTime.
+func NewManagerWithDSN(candidate string, dsn string, opts ...Option) (*Manager, error) {
+	o := newOptions(opts)
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+	db, err := openDB(dsn, o)
+	if err != nil {
+		return nil, err
+	}
+	return &Manager{
+		candidate:       candidate,
+		db:              db,
+		opts:            opts,
+		o:               o,
+		multiStatements: multiStatementsDSN(dsn),
+		redactedDSN:     redactDSN(dsn),
+	}, nil
+}
+
+// NewElection creates an election of the Manager, sharing its connection pool.
+func (m *Manager) NewElection(name string, opts ...Option) (*Election, error) {
+	election, err := newElection(name, m.candidate, append(slices.Clip(m.opts), opts...))
+	if err != nil {
+		return nil, err
+	}
+	election.db = m.db
+	election.multiStatements = m.multiStatements
+	election.redactedDSN = m.redactedDSN
+	if err = election.attach(); err != nil {
+		return nil, err
+	}
+	return election, nil
+}
+
+// Close closes the connection pool shared by the Manager's elections. Close the elections created with NewElection
+// and wait for RunAll to return first: an election still running on the pool fails its queries once it is closed.
+// Closing the Manager again is a no-op.
+func (m *Manager) Close() error {
+	if m.closed.Swap(true) {
+		return nil
+	}
+	sqlDB, err := m.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// LeadingElections returns the names of all elections in which candidate holds a valid lease, as judged by the lease
+// options of the Manager, in one query: the inverse of Election.IsLeader, giving a node one view of its leadership
+// responsibilities. Elections whose options override the lease options may be judged differently than by their own
+// candidates. Names hashed by WithLongNameHashing are returned in full.
+func (m *Manager) LeadingElections(ctx context.Context, candidate string) ([]string, error) {
+	ctx = orBackground(ctx)
+	ctx, cancel := context.WithTimeout(ctx, m.o.queryTimeout)
+	defer cancel()
+	// an election only to build the query against the Manager's tables and lease
+	e := &Election{candidate: candidate, db: m.db, opts: m.o, logger: m.o.logger}
+	if err := e.resolveTables(); err != nil {
+		return nil, err
+	}
+	var names []string
+	sql := `SELECT COALESCE(NULLIF(original_name, ''), election_name) FROM {records}
+			WHERE ` + isCandidate + ` and ` + e.leaseParams().held() + ` ORDER BY election_name`
+	if err := m.db.WithContext(ctx).Raw(e.readSQL(sql), candidate).Scan(&names).Error; err != nil {
+		return nil, ctxError(ctx, err)
+	}
+	return names, nil
+}
+
+// RunAll runs the elections described by specs until ctx is done, each like Election.Run. The first campaigns are
+// spread over a renew interval, so the elections don't all query the database at the same moment, and at most
+// WithMaxConcurrentCampaigns of them campaign at once. Leaders don't renew
+// on their own: the leases held are renewed together by RenewBatch, every renew interval of the Manager's options,
+// except for elections whose ElectionSpec.Options set a shorter renew interval, which renew on their own. An
+// election that stops doesn't stop the others; RunAll returns once all of them have stopped, with their errors joined.
+// Pass WithOnTransition to NewManager to observe the transitions of all the elections in one place.
+func (m *Manager) RunAll(ctx context.Context, specs []ElectionSpec) error {
+	ctx = orBackground(ctx)
+	batch := newRenewBatch(m, m.o.renewInterval)
+	campaigns := newCampaignLimiter(m.o.maxCampaigns)
+	elections := make([]*Election, len(specs))
+	for i, spec := range specs {
+		election, err := m.NewElection(spec.Name, spec.Options...)
+		if err != nil {
+			return fmt.Errorf("election %q: %w", spec.Name, err)
+		}
+		if election.opts.renewInterval >= batch.interval {
+			// an election renewing more often than the batch, e.g. for a shorter lease, renews on its own
+			election.batch = batch
+		}
+		election.campaigns = campaigns
+		elections[i] = election
+	}
+
+	batchCtx, stopBatch := context.WithCancel(ctx)
+	defer stopBatch()
+	go batch.run(batchCtx)
+
+	errs := make([]error, len(elections))
+	var wg sync.WaitGroup
+	for i, election := range elections {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			offset := election.opts.renewInterval * time.Duration(i) / time.Duration(len(elections))
+			if err := sleep(ctx, offset); err != nil {
+				errs[i] = err
+				return
+			}
+			spec := specs[i]
+			if err := election.Run(ctx, callbackOrNop(spec.OnBecomeLeader), callbackOrNop(spec.OnLoseLeadership)); err != nil {
+				errs[i] = fmt.Errorf("election %q: %w", spec.Name, err)
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+func callbackOrNop(cb CallbackFunc) CallbackFunc {
+	if cb == nil {
+		return func() {}
+	}
+	return cb
+}
+
+// campaignLimiter is a semaphore bounding the campaigns of a Manager's elections, see WithMaxConcurrentCampaigns. A nil
+// campaignLimiter doesn't limit them.
+type campaignLimiter chan struct{}
+
+func newCampaignLimiter(n int) campaignLimiter {
+	return make(campaignLimiter, n)
+}
+
+// acquire waits for a campaign slot, returning the function that releases it.
+func (l campaignLimiter) acquire(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	select {
+	case l <- struct{}{}:
+		return func() { <-l }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,71 @@
+package leaderelection
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newFakeManager creates a Manager querying db, for the candidate "candidate".
+func newFakeManager(t *testing.T, db *fakeDB) *Manager {
+	t.Helper()
+	pool, err := db.open()
+	if err != nil {
+		t.Fatalf("gorm.Open: %v", err)
+	}
+	m := &Manager{candidate: "candidate", db: pool, opts: []Option{quietLogs}, o: newOptions([]Option{quietLogs})}
+	t.Cleanup(func() { _ = m.Close() })
+	return m
+}
+
+func TestManagerCloseClosesSharedPool(t *testing.T) {
+	m := newFakeManager(t, &fakeDB{})
+	sqlDB, err := m.db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := m.NewElection("test")
+	if err != nil {
+		t.Fatalf("NewElection: %v", err)
+	}
+	// closing an election leaves the pool to the Manager
+	if err := e.Close(); err != nil {
+		t.Fatalf("Election.Close() = %v", err)
+	}
+	if err := sqlDB.Ping(); err != nil {
+		t.Fatalf("the shared pool was closed with an election: %v", err)
+	}
+	if err := m.Close(); err != nil {
+		t.Fatalf("Manager.Close() = %v", err)
+	}
+	if err := sqlDB.Ping(); err == nil {
+		t.Fatal("the shared pool still answers after Manager.Close")
+	}
+	if err := m.Close(); err != nil {
+		t.Fatalf("closing the Manager again = %v, want a no-op", err)
+	}
+}
+
+// TestRunAllRenewsShortLeasesOnTheirOwn checks that an election whose spec sets a lease shorter than the Manager's
+// renew interval keeps renewing it, rather than waiting for a batch that comes after the lease expired.
+func TestRunAllRenewsShortLeasesOnTheirOwn(t *testing.T) {
+	db := &fakeDB{handle: leaseHolder("candidate")}
+	m := newFakeManager(t, db)
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_ = m.RunAll(ctx, []ElectionSpec{{Name: "batched"}, {Name: "short", Options: shortLease}})
+	campaigns := make(map[any]int)
+	for _, query := range db.received() {
+		if strings.HasPrefix(query.sql, "INSERT INTO election_records") {
+			campaigns[query.args[0].Value]++
+		}
+	}
+	// the 30ms lease is renewed every 10ms, while the batch interval is the default 15s
+	if campaigns["short"] < 5 {
+		t.Errorf("the short lease was campaigned for %d times, want it renewed on its own", campaigns["short"])
+	}
+	if campaigns["batched"] != 1 {
+		t.Errorf("the batched election campaigned %d times, want once before waiting for the batch", campaigns["batched"])
+	}
+}
@@ -0,0 +1,199 @@
+package leaderelection
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// MemoryRegistry stores the leases of in-memory elections. Elections sharing a registry compete like candidates
+// sharing a database, but only within one process: it is meant for local development and tests, not production.
+type MemoryRegistry struct {
+	mu     sync.Mutex
+	leases map[string]*memoryLease
+}
+
+type memoryLease struct {
+	leader     string
+	term       uint64
+	lastUpdate time.Time
+	holdUntil  time.Time
+	// correlationID is only kept for the term it was stamped for.
+	correlationID string
+	correlated    uint64
+}
+
+// NewMemoryRegistry returns an empty registry.
+func NewMemoryRegistry() *MemoryRegistry {
+	return &MemoryRegistry{leases: make(map[string]*memoryLease)}
+}
+
+// NewMemoryElection creates an election whose leases live in registry instead of MySQL, so candidates in one process
+// can be elected without a database. With a nil registry the election gets one of its own, and therefore always wins.
+// Features that are inherently SQL, such as History, return an error wrapping errors.ErrUnsupported.
+func NewMemoryElection(registry *MemoryRegistry, name string, candidate string, opts ...Option) (*Election, error) {
+	election, err := newElection(name, candidate, opts)
+	if err != nil {
+		return nil, err
+	}
+	if registry == nil {
+		registry = NewMemoryRegistry()
+	}
+	election.memory = registry
+	return election, nil
+}
+
+func errMemoryUnsupported(feature string) error {
+	return fmt.Errorf("%s is not supported by in-memory elections: %w", feature, errors.ErrUnsupported)
+}
+
+// held returns the lease of e's election if it is still valid, mirroring the SQL lease arithmetic. Callers hold r.mu.
+func (r *MemoryRegistry) held(e *Election, now time.Time) *memoryLease {
+	lease, ok := r.leases[e.storedName]
+	if !ok || lease.lastUpdate.Before(now.Add(-e.opts.leaseDuration)) {
+		return nil
+	}
+	return lease
+}
+
+func (r *MemoryRegistry) campaign(e *Election) bool {
+	won, _ := r.contest(e)
+	return won
+}
+
+// contest campaigns for e's election, returning whether e won and otherwise the candidate whose lease it may not take
+// over, which is still on the row even when its lease expired within the minimum hold period, the clock skew
+// tolerance or the expiry jitter.
+func (r *MemoryRegistry) contest(e *Election) (bool, string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	if lease := r.held(e, now); lease != nil {
+		if lease.leader != e.candidate {
+			return false, lease.leader
+		}
+		lease.lastUpdate = now
+		return true, e.candidate
+	}
+	lease, ok := r.leases[e.storedName]
+	if !ok {
+		lease = &memoryLease{}
+		r.leases[e.storedName] = lease
+	} else if now.Before(lease.holdUntil) || (lease.leader != e.candidate &&
+		now.Before(lease.lastUpdate.Add(e.opts.leaseDuration+e.opts.skewTolerance+e.expiryJitter()))) {
+		// expired, but still within the minimum hold period of its acquisition, or the clock skew tolerance and expiry
+		// jitter that challengers wait out; the leader's own lapsed lease is taken over under a new term
+		if lease.leader != e.candidate {
+			return false, lease.leader
+		}
+		lease.lastUpdate = now
+		return true, e.candidate
+	}
+	lease.leader, lease.term, lease.lastUpdate = e.candidate, lease.term+1, now
+	lease.holdUntil = now.Add(e.opts.minHold)
+	return true, e.candidate
+}
+
+// campaignOrFollow campaigns and reports the leader to follow in one step, so a lease expiring in between can't leave
+// the candidate following nobody.
+func (r *MemoryRegistry) campaignOrFollow(e *Election) (bool, string, error) {
+	won, leader := r.contest(e)
+	return won, leader, nil
+}
+
+func (r *MemoryRegistry) renew(e *Election) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	lease := r.held(e, now)
+	if lease == nil || (lease.leader != e.candidate && !slices.Contains(e.candidateAliases(), lease.leader)) {
+		return false
+	}
+	lease.leader = e.candidate
+	lease.lastUpdate = now
+	return true
+}
+
+func (r *MemoryRegistry) renewTerm(e *Election, term uint64) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	lease := r.held(e, now)
+	if lease == nil || lease.leader != e.candidate || lease.term != term {
+		return false
+	}
+	lease.lastUpdate = now
+	return true
+}
+
+func (r *MemoryRegistry) resign(e *Election) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if lease := r.held(e, time.Now()); lease != nil && lease.leader == e.candidate {
+		lease.lastUpdate = time.Time{}
+	}
+}
+
+func (r *MemoryRegistry) leaderInfo(e *Election) (LeaderInfo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	lease := r.held(e, time.Now())
+	if lease == nil {
+		return LeaderInfo{}, ErrNoLeader
+	}
+	info := LeaderInfo{Leader: lease.leader, Term: lease.term}
+	if lease.correlated == lease.term {
+		info.CorrelationID = lease.correlationID
+	}
+	return info, nil
+}
+
+func (r *MemoryRegistry) stampCorrelation(e *Election, term uint64, id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if lease, ok := r.leases[e.storedName]; ok && lease.leader == e.candidate && lease.term == term {
+		lease.correlationID, lease.correlated = id, term
+	}
+}
+
+func (r *MemoryRegistry) reset(e *Election) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if lease, ok := r.leases[e.storedName]; ok {
+		*lease = memoryLease{term: lease.term}
+	}
+}
+
+func (r *MemoryRegistry) isLeader(e *Election) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	lease := r.held(e, time.Now())
+	if lease == nil || (lease.leader != e.candidate && !slices.Contains(e.candidateAliases(), lease.leader)) {
+		return false
+	}
+	e.term.Store(lease.term)
+	return true
+}
+
+func (r *MemoryRegistry) timeUntilExpiry(e *Election) (time.Duration, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	lease := r.held(e, now)
+	if lease == nil || lease.leader != e.candidate {
+		return 0, ErrLeaseLost
+	}
+	return lease.lastUpdate.Add(e.opts.leaseDuration).Sub(now), nil
+}
+
+func (r *MemoryRegistry) getLeader(e *Election) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	lease := r.held(e, time.Now())
+	if lease == nil {
+		return "", ErrNoLeader
+	}
+	return lease.leader, nil
+}
@@ -0,0 +1,182 @@
+package leaderelection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// shortLease lets in-memory leases expire within a test.
+var shortLease = []Option{
+	quietLogs,
+	WithMicrosecondPrecision(),
+	WithLeaseDuration(30 * time.Millisecond),
+	WithRenewInterval(10 * time.Millisecond),
+}
+
+func newMemoryCandidate(t *testing.T, registry *MemoryRegistry, candidate string, opts ...Option) *Election {
+	t.Helper()
+	e, err := NewMemoryElection(registry, "test", candidate, append(shortLease, opts...)...)
+	if err != nil {
+		t.Fatalf("NewMemoryElection(%q): %v", candidate, err)
+	}
+	return e
+}
+
+func TestMemoryCampaignOrFollowWithinMinHold(t *testing.T) {
+	registry := NewMemoryRegistry()
+	a := newMemoryCandidate(t, registry, "a", WithMinHoldDuration(time.Second))
+	b := newMemoryCandidate(t, registry, "b", WithMinHoldDuration(time.Second))
+	if won, err := a.Campaign(context.Background()); err != nil || !won {
+		t.Fatalf("a.Campaign() = %v, %v, want true", won, err)
+	}
+	// a's lease expires, but its minimum hold period keeps b from taking over
+	time.Sleep(40 * time.Millisecond)
+
+	done := make(chan struct{})
+	var won bool
+	var leader string
+	var err error
+	go func() {
+		defer close(done)
+		won, leader, err = b.CampaignOrFollow(context.Background())
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("CampaignOrFollow did not return within the minimum hold period")
+	}
+	if err != nil || won || leader != "a" {
+		t.Fatalf("b.CampaignOrFollow() = %v, %q, %v, want false, \"a\"", won, leader, err)
+	}
+}
+
+// TestMemoryLeaseDecisionsAgree checks that campaigning, renewing and verifying agree on whether a lease is held,
+// before and after it expires.
+func TestMemoryLeaseDecisionsAgree(t *testing.T) {
+	ctx := context.Background()
+	registry := NewMemoryRegistry()
+	a := newMemoryCandidate(t, registry, "a")
+	b := newMemoryCandidate(t, registry, "b")
+	if won, err := a.Campaign(ctx); err != nil || !won {
+		t.Fatalf("a.Campaign() = %v, %v, want true", won, err)
+	}
+	if isLeader, err := a.IsLeader(ctx); err != nil || !isLeader {
+		t.Fatalf("a.IsLeader() = %v, %v, want true while the lease is held", isLeader, err)
+	}
+	if renewed, err := a.Renew(ctx); err != nil || !renewed {
+		t.Fatalf("a.Renew() = %v, %v, want true while the lease is held", renewed, err)
+	}
+	if won, err := b.Campaign(ctx); err != nil || won {
+		t.Fatalf("b.Campaign() = %v, %v, want false while a holds the lease", won, err)
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	if isLeader, err := a.IsLeader(ctx); err != nil || isLeader {
+		t.Fatalf("a.IsLeader() = %v, %v, want false once the lease expired", isLeader, err)
+	}
+	if renewed, err := a.Renew(ctx); err != nil || renewed {
+		t.Fatalf("a.Renew() = %v, %v, want false once the lease expired", renewed, err)
+	}
+	if won, err := b.Campaign(ctx); err != nil || !won {
+		t.Fatalf("b.Campaign() = %v, %v, want true once a's lease expired", won, err)
+	}
+	if isLeader, err := b.IsLeader(ctx); err != nil || !isLeader {
+		t.Fatalf("b.IsLeader() = %v, %v, want true after taking over", isLeader, err)
+	}
+	if term := b.Term(); term != 2 {
+		t.Fatalf("b.Term() = %d, want 2 after taking over", term)
+	}
+}
+
+func TestMemoryConcurrentFirstCampaigns(t *testing.T) {
+	const candidates = 20
+	registry := NewMemoryRegistry()
+	elections := make([]*Election, candidates)
+	for i := range elections {
+		elections[i] = newMemoryCandidate(t, registry, fmt.Sprintf("candidate-%d", i))
+	}
+	var wg sync.WaitGroup
+	var winners atomic.Int32
+	errs := make([]error, candidates)
+	start := make(chan struct{})
+	for i, e := range elections {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			won, err := e.Campaign(context.Background())
+			errs[i] = err
+			if won {
+				winners.Add(1)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		t.Fatalf("concurrent first campaigns failed: %v", err)
+	}
+	if n := winners.Load(); n != 1 {
+		t.Fatalf("%d candidates won the first campaign, want exactly 1", n)
+	}
+}
+
+// TestMemoryLapsedLeaderStartsNewTerm checks that a leader campaigning after its lease lapsed, while challengers still
+// wait out the clock skew tolerance, wins a new term rather than renewing the lapsed one.
+func TestMemoryLapsedLeaderStartsNewTerm(t *testing.T) {
+	ctx := context.Background()
+	registry := NewMemoryRegistry()
+	a := newMemoryCandidate(t, registry, "a", WithClockSkewTolerance(time.Second))
+	b := newMemoryCandidate(t, registry, "b", WithClockSkewTolerance(time.Second))
+	if won, err := a.Campaign(ctx); err != nil || !won {
+		t.Fatalf("a.Campaign() = %v, %v, want true", won, err)
+	}
+	if isLeader, err := a.IsLeader(ctx); err != nil || !isLeader {
+		t.Fatalf("a.IsLeader() = %v, %v, want true while the lease is held", isLeader, err)
+	}
+	first := a.Term()
+
+	time.Sleep(40 * time.Millisecond)
+	if won, err := b.Campaign(ctx); err != nil || won {
+		t.Fatalf("b.Campaign() = %v, %v, want false within the skew tolerance", won, err)
+	}
+	if won, err := a.Campaign(ctx); err != nil || !won {
+		t.Fatalf("a.Campaign() = %v, %v, want true after its lease lapsed", won, err)
+	}
+	if isLeader, err := a.IsLeader(ctx); err != nil || !isLeader {
+		t.Fatalf("a.IsLeader() = %v, %v, want true after campaigning again", isLeader, err)
+	}
+	if term := a.Term(); term != first+1 {
+		t.Fatalf("a.Term() = %d, want %d after its lease lapsed", term, first+1)
+	}
+}
+
+func TestMemoryResetKeepsTerm(t *testing.T) {
+	ctx := context.Background()
+	registry := NewMemoryRegistry()
+	a := newMemoryCandidate(t, registry, "a")
+	b := newMemoryCandidate(t, registry, "b")
+	if won, err := a.Campaign(ctx); err != nil || !won {
+		t.Fatalf("a.Campaign() = %v, %v, want true", won, err)
+	}
+	if err := a.Reset(ctx); err != nil {
+		t.Fatalf("Reset() = %v", err)
+	}
+	if leader, err := b.GetLeader(ctx); !errors.Is(err, ErrNoLeader) {
+		t.Fatalf("GetLeader() = %q, %v, want ErrNoLeader after Reset", leader, err)
+	}
+	if won, err := b.Campaign(ctx); err != nil || !won {
+		t.Fatalf("b.Campaign() = %v, %v, want true after Reset", won, err)
+	}
+	if isLeader, err := b.IsLeader(ctx); err != nil || !isLeader {
+		t.Fatalf("b.IsLeader() = %v, %v, want true", isLeader, err)
+	}
+	if term := b.Term(); term != 2 {
+		t.Fatalf("b.Term() = %d, want 2 after Reset", term)
+	}
+}
@@ -0,0 +1,123 @@
+package leaderelection
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Metrics receives operational measurements from RunElection, for export to a metrics system such as Prometheus.
+// Every measurement carries the election name, so one implementation can serve several elections. Implementations
+// must be safe for concurrent use and should return quickly, as they are called from the election loop.
+type Metrics interface {
+	// IncCampaigns counts one campaign by its outcome.
+	IncCampaigns(election string, outcome Outcome)
+	// SetLeading reports whether this candidate currently leads the election.
+	SetLeading(election string, leading bool)
+	// ObserveRenewLatency records how long one renewal query took, whether or not it succeeded.
+	ObserveRenewLatency(election string, latency time.Duration)
+	// SetLeaseAge reports how long ago the leader last renewed its lease successfully, sampled just before each
+	// renewal, when it is largest. It is zero while not leading.
+	SetLeaseAge(election string, age time.Duration)
+}
+
+// The operations reported to the WithOnOperation hook.
+const (
+	OperationCampaign = "campaign"
+	OperationRenew    = "renew"
+	OperationIsLeader = "is_leader"
+	OperationResign   = "resign"
+)
+
+// observeOperation reports the database operation op, started at started, to the WithOnOperation hook.
+func (e *Election) observeOperation(op string, started time.Time, err error) {
+	if e.opts.onOperation != nil {
+		e.opts.onOperation(op, time.Since(started), err)
+	}
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) IncCampaigns(string, Outcome)              {}
+func (nopMetrics) SetLeading(string, bool)                   {}
+func (nopMetrics) ObserveRenewLatency(string, time.Duration) {}
+func (nopMetrics) SetLeaseAge(string, time.Duration)         {}
+
+// LeaseAge returns how long ago RunElection last acquired or renewed this candidate's lease, or zero while it isn't
+// leading. Unlike TimeUntilExpiry it doesn't query the database, so it can back a gauge that is read on every scrape.
+func (e *Election) LeaseAge() time.Duration {
+	renewed := e.renewedAt.Load()
+	if renewed == 0 {
+		return 0
+	}
+	return time.Since(time.Unix(0, renewed))
+}
+
+// outcomes are all outcomes, in the order WriteMetrics writes them.
+var outcomes = []Outcome{
+	OutcomeWon, OutcomeRenewed, OutcomeLost, OutcomeResigned, OutcomeNotAcquired, OutcomeUnverified, OutcomeError,
+}
+
+// electionCounters are the counters WriteMetrics exposes, kept by the election itself.
+type electionCounters struct {
+	mu          sync.Mutex
+	campaigns   map[Outcome]uint64
+	transitions atomic.Uint64
+}
+
+// countingMetrics counts campaigns for WriteMetrics before passing them on.
+type countingMetrics struct {
+	Metrics
+	counters *electionCounters
+}
+
+func (m countingMetrics) IncCampaigns(election string, outcome Outcome) {
+	m.counters.mu.Lock()
+	if m.counters.campaigns == nil {
+		m.counters.campaigns = make(map[Outcome]uint64, len(outcomes))
+	}
+	m.counters.campaigns[outcome]++
+	m.counters.mu.Unlock()
+	m.Metrics.IncCampaigns(election, outcome)
+}
+
+// WriteMetrics writes the election's state in the Prometheus text exposition format, for serving from a metrics
+// endpoint without a Prometheus client library: whether this candidate leads, the term, the lease age, and counters
+// of leadership transitions and of campaigns by outcome, all labelled with the election and candidate names. The
+// counters cover e's Run loop since the election was created.
+func (e *Election) WriteMetrics(w io.Writer) error {
+	labels := fmt.Sprintf(`election="%s",candidate="%s"`, escapeLabel(e.name), escapeLabel(e.candidate))
+	var b strings.Builder
+	family := func(name, kind, help string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+	leading := 0
+	if e.leading.Load() {
+		leading = 1
+	}
+	family("leaderelection_leading", "gauge", "Whether this candidate leads the election.")
+	fmt.Fprintf(&b, "leaderelection_leading{%s} %d\n", labels, leading)
+	family("leaderelection_term", "gauge", "The term held, or last held, by this candidate.")
+	fmt.Fprintf(&b, "leaderelection_term{%s} %d\n", labels, e.Term())
+	family("leaderelection_lease_age_seconds", "gauge", "Time since the lease was last renewed, zero while not leading.")
+	fmt.Fprintf(&b, "leaderelection_lease_age_seconds{%s} %g\n", labels, e.LeaseAge().Seconds())
+	family("leaderelection_transitions_total", "counter", "Leadership transitions of this candidate.")
+	fmt.Fprintf(&b, "leaderelection_transitions_total{%s} %d\n", labels, e.counters.transitions.Load())
+	family("leaderelection_campaigns_total", "counter", "Campaigns and renewals of this candidate, by outcome.")
+	e.counters.mu.Lock()
+	for _, outcome := range outcomes {
+		fmt.Fprintf(&b, "leaderelection_campaigns_total{%s,outcome=\"%s\"} %d\n", labels, outcome,
+			e.counters.campaigns[outcome])
+	}
+	e.counters.mu.Unlock()
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// escapeLabel escapes a label value for the Prometheus text format.
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
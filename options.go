@@ -0,0 +1,805 @@
+package leaderelection
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+// Option customises an Election and the loop that drives it.
+type Option func(*options)
+
+type options struct {
+	logger            *slog.Logger
+	leaseDuration     time.Duration
+	renewInterval     time.Duration
+	missedRenewals    int
+	queryTimeout      time.Duration
+	acquireTimeout    time.Duration
+	identity          IdentityProvider
+	dedicatedConn     bool
+	maxRenewFailures  int
+	maxCampaigns      int
+	history           bool
+	stats             bool
+	onStoppedLeading  func(LossReason)
+	backoff           BackoffStrategy
+	autoMigrate       bool
+	namingStrategy    schema.Namer
+	hashLongNames     bool
+	fence             func(ctx context.Context, term uint64) error
+	validator         func(ctx context.Context) error
+	onStartedLeading  func(ctx context.Context)
+	executor          CallbackExecutor
+	shutdownGrace     time.Duration
+	writeHint         string
+	readHint          string
+	metrics           Metrics
+	preferredLeader   string
+	yieldGrace        time.Duration
+	sink              StateSink
+	rowsAffectedFast  bool
+	registerCandidate bool
+	adaptiveFraction  float64
+	adaptiveFloor     time.Duration
+	isRetryable       func(error) bool
+	safetyFactor      float64
+	onTransition      func(LeadershipEvent)
+	bindBackend       bool
+	sqlBuilder        SQLBuilder
+	combinedVerify    bool
+	minHold           time.Duration
+	onCampaign        func(Outcome, time.Duration, error)
+	onOperation       func(string, time.Duration, error)
+	fallbackPath      string
+	fallbackAfter     time.Duration
+	lightRenewal      bool
+	createOnlyMigrate bool
+	fairnessDelay     time.Duration
+	postLossCooldown  time.Duration
+	skewTolerance     time.Duration
+	expiryJitter      time.Duration
+	longLeadership    time.Duration
+	onLongLeadership  func(held time.Duration)
+	sessionVars       map[string]string
+	microseconds      bool
+	epochLease        bool
+	stealBackoff      time.Duration
+	zone              string
+	preferredZone     string
+	zoneGrace         time.Duration
+	correlationID     func(ctx context.Context) string
+	schema            string
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		logger:           slog.Default(),
+		leaseDuration:    60 * time.Second,
+		renewInterval:    15 * time.Second,
+		missedRenewals:   -1,
+		identity:         HostIdentity(),
+		executor:         goExecutor,
+		maxRenewFailures: 3,
+		maxCampaigns:     defaultMaxCampaigns,
+		backoff:          ConstantBackoff(60 * time.Second),
+		autoMigrate:      true,
+		metrics:          nopMetrics{},
+		isRetryable:      IsRetryable,
+		safetyFactor:     3,
+		sqlBuilder:       MySQLBuilder{},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.queryTimeout == 0 {
+		o.queryTimeout = min(5*time.Second, o.renewInterval/2)
+	}
+	return o
+}
+
+// WithLogger sets the logger used for election lifecycle events. Defaults to slog.Default().
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		if logger != nil {
+			o.logger = logger
+		}
+	}
+}
+
+// WithLeaseDuration sets how long a leader's lease stays valid without renewal before another candidate may take
+// over. The lease is tracked with whole-second precision. Defaults to 60s.
+func WithLeaseDuration(d time.Duration) Option {
+	return func(o *options) {
+		o.leaseDuration = d
+	}
+}
+
+// WithRenewInterval sets how often a leader renews its lease. It must be shorter than the lease. Defaults to 15s.
+func WithRenewInterval(d time.Duration) Option {
+	return func(o *options) {
+		o.renewInterval = d
+	}
+}
+
+// WithMissedRenewals configures the lease in terms of the renewal cadence instead of a raw duration: leaders renew
+// every renewInterval and keep their lease through missed consecutive failed renewals, which gives
+// lease = renewInterval * (missed + 1). It replaces WithLeaseDuration and WithRenewInterval, and combining it with a
+// conflicting lease duration is a configuration error. The lease must still span the renew intervals of
+// WithSafetyFactor, so with the default factor of 3 at least 2 missed renewals are needed; a factor of 1 allows 1.
+func WithMissedRenewals(renewInterval time.Duration, missed int) Option {
+	return func(o *options) {
+		o.renewInterval = renewInterval
+		o.missedRenewals = missed
+		o.leaseDuration = renewInterval * time.Duration(missed+1)
+	}
+}
+
+// WithQueryTimeout bounds how long any single election query may run, even when the caller's context has no deadline,
+// so a hung query can't silently outlast the renewal window. It can't exceed the renew interval. Defaults to the
+// smaller of 5s and half the renew interval.
+func WithQueryTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.queryTimeout = d
+	}
+}
+
+// WithAcquireTimeout bounds how long Campaign waits on a congested election row. A campaign that times out, or that
+// hits InnoDB's innodb_lock_wait_timeout, reports "not acquired this round" instead of an error, keeping the loop
+// responsive. A leader renewing in RunElection counts it as a failed renewal instead, see WithMaxRenewFailures, as its
+// lease is still valid. Deadlocks are still reported as errors: InnoDB rolls the victim back immediately, and the loop
+// simply campaigns again next round. It can't exceed the query timeout. Disabled by default.
+func WithAcquireTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.acquireTimeout = d
+	}
+}
+
+// WithIdentityProvider sets how RunElection names this process's candidate. Defaults to HostIdentity().
+func WithIdentityProvider(p IdentityProvider) Option {
+	return func(o *options) {
+		if p != nil {
+			o.identity = p
+		}
+	}
+}
+
+// WithoutMACLookup makes RunElection name the candidate with HostIdentityWithoutMAC instead of HostIdentity, so the
+// network interfaces are never listed. Like WithIdentityProvider, of the two the one given last applies.
+func WithoutMACLookup() Option {
+	return func(o *options) {
+		o.identity = HostIdentityWithoutMAC()
+	}
+}
+
+// WithDedicatedConn makes RunElection reserve a single connection from the pool for the election loop, so campaigns
+// and renewals are never queued behind other queries on a saturated pool. The reserved connection is held for the
+// lifetime of the loop and is therefore not recycled by the pool's connection lifetime.
+func WithDedicatedConn() Option {
+	return func(o *options) {
+		o.dedicatedConn = true
+	}
+}
+
+// WithMaxRenewFailures sets how many consecutive renewal errors a leader tolerates before it proactively steps
+// down, firing the lose callback even though its lease may not have expired on the server yet. A successful renewal
+// resets the count. Defaults to 3.
+func WithMaxRenewFailures(n int) Option {
+	return func(o *options) {
+		o.maxRenewFailures = n
+	}
+}
+
+// defaultMaxCampaigns is the default WithMaxConcurrentCampaigns limit.
+const defaultMaxCampaigns = 16
+
+// WithMaxConcurrentCampaigns bounds how many of a Manager's elections campaign (or renew outside the batch) at the same
+// time, so that a process taking part in hundreds of elections doesn't overwhelm the database when their campaigns
+// coincide; the others wait their turn. Renewals batched by RunAll are not counted, as they are one query for all the
+// leases held. It applies to the elections run by Manager.RunAll, and defaults to 16.
+func WithMaxConcurrentCampaigns(n int) Option {
+	return func(o *options) {
+		o.maxCampaigns = n
+	}
+}
+
+// WithHistory records every leadership takeover in the append-only election_history table, queryable through
+// Election.History. It costs an extra write per term won, so it is disabled by default.
+func WithHistory() Option {
+	return func(o *options) {
+		o.history = true
+	}
+}
+
+// WithStats keeps a durable tally of the election's takeovers in the election_stats table, queryable through
+// Election.ElectionStats, so how often leadership changed survives restarts. It costs an extra write per term won, so
+// it is disabled by default.
+func WithStats() Option {
+	return func(o *options) {
+		o.stats = true
+	}
+}
+
+// WithOnStoppedLeading registers a callback that receives the reason whenever RunElection's lose callback fires. It
+// runs right after the lose callback, on the same goroutine.
+func WithOnStoppedLeading(fn func(reason LossReason)) Option {
+	return func(o *options) {
+		o.onStoppedLeading = fn
+	}
+}
+
+// WithBackoffStrategy sets how long RunElection waits between attempts to acquire leadership. Defaults to
+// ConstantBackoff(60 * time.Second).
+func WithBackoffStrategy(strategy BackoffStrategy) Option {
+	return func(o *options) {
+		if strategy != nil {
+			o.backoff = strategy
+		}
+	}
+}
+
+// WithoutAutoMigrate stops the election from creating or updating its tables, for deployments that manage the schema
+// themselves. The election then reports a missing table as ErrTableMissing instead of recreating it.
+func WithoutAutoMigrate() Option {
+	return func(o *options) {
+		o.autoMigrate = false
+	}
+}
+
+// WithCreateOnlyMigrate limits auto-migration to creating the tables that don't exist yet: existing tables are never
+// altered, e.g. when a previous version created last_update as timestamp rather than datetime, where an ALTER could
+// fail or quietly change time zone behaviour on a production table. Instead, the election table's column types are
+// checked on startup and mismatches are logged as warnings. Columns added by later versions then have to be added by
+// hand.
+func WithCreateOnlyMigrate() Option {
+	return func(o *options) {
+		o.createOnlyMigrate = true
+	}
+}
+
+// WithSessionVariables sets MySQL session variables on every connection the election opens, e.g. time_zone or
+// sql_mode, so lease comparisons behave the same on every connection of the pool. Values are SQL expressions, so
+// strings must be quoted: map[string]string{"time_zone": "'+00:00'", "wait_timeout": "600"}. They are set right after
+// connecting, and override any session variables of the same name in the DSN.
+func WithSessionVariables(vars map[string]string) Option {
+	return func(o *options) {
+		o.sessionVars = vars
+	}
+}
+
+// WithSchema qualifies the election's tables with the database (schema) name, e.g. tenant_a.election_records, in all
+// queries and in auto-migration, so per-tenant elections can share one connection pool while keeping their tables in
+// distinct schemas. The name must be a plain MySQL identifier: letters, digits, '_' and '$', up to 64 characters.
+func WithSchema(name string) Option {
+	return func(o *options) {
+		o.schema = name
+	}
+}
+
+// WithNamingStrategy sets the GORM naming strategy that maps the election models to table names, e.g. to apply the
+// table prefix the rest of an application's models use. The election's queries use the same names AutoMigrate creates.
+// HistoryEntry always maps to election_history, as it names its table explicitly.
+func WithNamingStrategy(namer schema.Namer) Option {
+	return func(o *options) {
+		o.namingStrategy = namer
+	}
+}
+
+// WithLongNameHashing lets elections have names longer than the 256 character election_name column: such names are
+// stored as a readable prefix followed by a hash of the full name, and the full name is kept in the original_name
+// column so operators can correlate the two. Without it, over-long names are rejected with ErrInvalidConfig.
+func WithLongNameHashing() Option {
+	return func(o *options) {
+		o.hashLongNames = true
+	}
+}
+
+// WithFence registers a function RunElection calls after winning the election but before declaring leadership, with
+// the term just won, so the term can be asserted as a fencing token on a downstream resource first. If fence fails, the
+// candidate resigns and campaigns again later, so only a leader whose fence succeeded ever acts as leader.
+func WithFence(fence func(ctx context.Context, term uint64) error) Option {
+	return func(o *options) {
+		o.fence = fence
+	}
+}
+
+// WithValidateLeadership registers a function RunElection calls after winning and verifying the election but before
+// declaring leadership, to cross-check it against another source of truth, e.g. that no one else holds a Consul lock.
+// If validate fails, the candidate resigns and campaigns again later. The check is advisory, layered on top of the
+// lease rather than replacing it, and is only made on acquisition, so its latency adds to every takeover. It runs
+// after the fence of WithFence, if any.
+func WithValidateLeadership(validate func(ctx context.Context) error) Option {
+	return func(o *options) {
+		o.validator = validate
+	}
+}
+
+// WithOnStartedLeading registers leader work that RunElection starts in its own goroutine whenever it wins the
+// election. The work's context derives from the one the election runs with, keeping its values, and is cancelled as
+// soon as leadership is lost, and when the election stops.
+func WithOnStartedLeading(fn func(ctx context.Context)) Option {
+	return func(o *options) {
+		o.onStartedLeading = fn
+	}
+}
+
+// WithCallbackExecutor sets how the election starts the goroutines its callbacks run on: the one delivering the
+// leadership callbacks of Run, which lives as long as the election runs, and the ones running the work of
+// WithOnStartedLeading and RunLeaderTask. Callbacks keep their ordering, as they are still delivered one at a time;
+// a panic recovered by exec stops the delivery of further callbacks. Defaults to starting a plain goroutine.
+func WithCallbackExecutor(exec CallbackExecutor) Option {
+	return func(o *options) {
+		if exec != nil {
+			o.executor = exec
+		}
+	}
+}
+
+// WithShutdownGrace sets how long a leader that is stopping waits for the work started by WithOnStartedLeading to
+// return after cancelling it, before it resigns. Defaults to 0, resigning straight away.
+func WithShutdownGrace(d time.Duration) Option {
+	return func(o *options) {
+		o.shutdownGrace = d
+	}
+}
+
+// WithQueryHints prefixes election statements with SQL comments for query-routing proxies such as ProxySQL or Vitess:
+// writeHint (e.g. "/* route:primary */") goes in front of the statements that modify the election (campaign, renew,
+// resign, ...), and readHint in front of read-only queries (IsLeader, GetLeader, ...). The hints are inserted verbatim.
+// Routing reads to replicas makes them subject to replication lag, including the verification RunElection performs
+// after each campaign.
+func WithQueryHints(writeHint string, readHint string) Option {
+	return func(o *options) {
+		o.writeHint = hintPrefix(writeHint)
+		o.readHint = hintPrefix(readHint)
+	}
+}
+
+// WithMetrics reports campaign outcomes, leadership state, renewal latency and lease age to m. Disabled by default.
+func WithMetrics(m Metrics) Option {
+	return func(o *options) {
+		if m != nil {
+			o.metrics = m
+		}
+	}
+}
+
+// WithPreferredLeader expresses a soft preference for the candidate named preferred: when RunElection finds the lease
+// free or expired, every other candidate waits for grace before claiming it, giving the preferred candidate the first
+// chance, while the preferred candidate claims it immediately. A leader is never asked to give up its lease for the
+// preferred candidate. The grace should be comfortably longer than the time the preferred candidate takes to notice a
+// free lease, i.e. its backoff between attempts.
+func WithPreferredLeader(preferred string, grace time.Duration) Option {
+	return func(o *options) {
+		o.preferredLeader = preferred
+		o.yieldGrace = grace
+	}
+}
+
+// WithEpochLease tracks leases by an integer epoch instead of DATETIME arithmetic: every acquisition and renewal sets
+// the expires_at_unix column to UNIX_TIMESTAMP() plus the lease, and a lease is held until UNIX_TIMESTAMP() passes it,
+// so lease expiry is immune to the session time zone and DST changes. last_update is still written, for information,
+// and the minimum hold period of WithMinHoldDuration is still tracked as DATETIME. Auto-migration adds the column.
+// Every candidate of the election must use it: a lease renewed without it leaves expires_at_unix behind, so it looks
+// expired to the candidates that do, and switching modes on a live election lets the lease be taken over once. It
+// times leases in whole seconds, so it can't be combined with WithMicrosecondPrecision.
+func WithEpochLease() Option {
+	return func(o *options) {
+		o.epochLease = true
+	}
+}
+
+// WithMicrosecondPrecision times leases with microsecond rather than whole-second precision, allowing sub-second
+// leases (down to a millisecond) for faster failover: the lease arithmetic uses NOW(6) and intervals in
+// microseconds, and auto-migration changes last_update and hold_until to DATETIME(6). Short leases mean frequent
+// renewals, so expect more load on the database. Every candidate of the election must use it: candidates timing in
+// seconds truncate the timestamps they write. For tables managed WithoutAutoMigrate or WithCreateOnlyMigrate, the
+// columns have to be changed by hand.
+func WithMicrosecondPrecision() Option {
+	return func(o *options) {
+		o.microseconds = true
+	}
+}
+
+// WithClockSkewTolerance widens the threshold for taking over a lease that wasn't renewed by d (rounded up to whole
+// seconds): challengers wait for the lease plus d, while the leader still considers its lease lapsed after the lease
+// alone, leaving d as a safety buffer between them. A leader campaigning again after its lease lapsed wins a new
+// term, so it steps down rather than carrying on under the lapsed one. Leases are timed by the database server's
+// clock, so this is only needed when the candidates' statements can be evaluated by servers whose clocks differ, e.g.
+// multi-primary setups or reads from replicas. Defaults to zero, relying on the server clock alone.
+func WithClockSkewTolerance(d time.Duration) Option {
+	return func(o *options) {
+		o.skewTolerance = d
+	}
+}
+
+// WithOnLongLeadership enables a watchdog for lost redundancy: once RunElection has held leadership continuously for
+// threshold, it logs a warning and calls fn, if not nil, with how long leadership has been held. A leader never
+// sees challengers that fail to win, so an unbroken tenure is the hint that no other candidate is running; with
+// WithCandidateRegistration, the warning is skipped while other registered candidates are seen participating. It
+// fires at most once per tenure, the timer restarting on each acquisition. fn runs on the election loop, so it
+// should return quickly. Disabled by default.
+func WithOnLongLeadership(threshold time.Duration, fn func(held time.Duration)) Option {
+	return func(o *options) {
+		o.longLeadership = threshold
+		o.onLongLeadership = fn
+	}
+}
+
+// WithExpiryJitter has this process wait a random extra offset, of up to limit, past the lease (and any clock skew
+// tolerance) before it considers a lease that wasn't renewed expired and takes it over, so followers reach that
+// conclusion at different times and the first claims the lease cleanly instead of all of them racing for it. The
+// offset is drawn once per process and only ever lengthens the lease, so a live lease is never stolen early; Resign
+// back-dates the lease past the largest offset. limit can be at most half the lease duration, and is rounded up to
+// whole seconds unless timed WithMicrosecondPrecision. Disabled by default.
+func WithExpiryJitter(limit time.Duration) Option {
+	return func(o *options) {
+		o.expiryJitter = limit
+	}
+}
+
+// WithStealBackoff staggers the candidates claiming an expired lease, to spare the database every follower writing
+// at once during a failover: a follower that finds the lease free waits up to limit before campaigning, half of it
+// set by a hash of its name and half at random. The first to campaign wins and the others find the lease taken, so
+// correctness is unaffected, but failover takes up to limit longer. Disabled by default.
+func WithStealBackoff(limit time.Duration) Option {
+	return func(o *options) {
+		o.stealBackoff = limit
+	}
+}
+
+// WithPostLossCooldown makes RunElection wait d after losing leadership, and firing the lose callback, before it
+// campaigns again, so two closely matched candidates don't keep trading leadership, and the new leader gets a chance
+// to settle. Defaults to zero, campaigning again straight away.
+func WithPostLossCooldown(d time.Duration) Option {
+	return func(o *options) {
+		o.postLossCooldown = d
+	}
+}
+
+// WithFairness spreads leadership more evenly across candidates that keep contending for a lease: a candidate that
+// stopped leading less than a lease duration ago, and finds the lease free, waits a random delay between delay/2 and
+// delay before campaigning for it, so candidates that haven't led recently get the first chance and the outgoing
+// leader rarely re-wins just by timing. The random share breaks ties between several recent leaders. The time
+// leadership was last held is only tracked in memory, so it resets when the process restarts. Disabled by default.
+func WithFairness(delay time.Duration) Option {
+	return func(o *options) {
+		o.fairnessDelay = delay
+	}
+}
+
+// WithZoneAffinity makes leadership prefer candidates in preferredZone, e.g. the availability zone closest to the
+// leader's dependencies: the candidate declares it runs in zone, and when it isn't the preferred zone and RunElection
+// finds the lease free or expired, it waits for grace before claiming it, so a candidate of the preferred zone can
+// claim it first. If none does, the lease is claimed all the same. As with WithPreferredLeader, a leader is never
+// asked to give up its lease, and the grace should be longer than a backoff between attempts.
+func WithZoneAffinity(zone, preferredZone string, grace time.Duration) Option {
+	return func(o *options) {
+		o.zone = zone
+		o.preferredZone = preferredZone
+		o.zoneGrace = grace
+	}
+}
+
+// WithCorrelationID stamps a correlation ID, e.g. the ID of the trace or deploy that started the candidate, on the
+// election row whenever RunElection acquires leadership, so a takeover can be traced back to what caused it. id is
+// called with the context the election runs with, so it can take the ID from there, or return a fixed one from
+// configuration; an empty ID isn't stamped. The ID is readable with GetLeaderInfo.
+func WithCorrelationID(id func(ctx context.Context) string) Option {
+	return func(o *options) {
+		o.correlationID = id
+	}
+}
+
+// WithStateSink mirrors the election state to sink on every transition and after every campaign. No sink by default.
+func WithStateSink(sink StateSink) Option {
+	return func(o *options) {
+		o.sink = sink
+	}
+}
+
+// WithExplicitOwnershipCheck makes Campaign decide whether it won by reading back the lease after every upsert.
+//
+// Deprecated: reading back the lease is the default; WithExplicitOwnershipCheck only undoes WithRowsAffectedFastPath.
+func WithExplicitOwnershipCheck() Option {
+	return func(o *options) {
+		o.rowsAffectedFast = false
+	}
+}
+
+// WithRowsAffectedFastPath makes Campaign decide whether it won from the affected-row count of the upsert where the
+// count is conclusive, saving the read-back of the lease it otherwise does on the same connection. The count is only
+// as reliable as the driver and any proxy in between: clients connecting with CLIENT_FOUND_ROWS, for one, can't tell
+// an unchanged row from a renewed one, so such counts are still read back.
+func WithRowsAffectedFastPath() Option {
+	return func(o *options) {
+		o.rowsAffectedFast = true
+	}
+}
+
+// WithCandidateRegistration makes RunElection record a heartbeat in the election_candidates table on every attempt and
+// renewal, so Election.Candidates can list who is participating. Followers only count as live while their backoff is
+// shorter than the lease. It costs an extra write per attempt, so it is disabled by default.
+func WithCandidateRegistration() Option {
+	return func(o *options) {
+		o.registerCandidate = true
+	}
+}
+
+// WithAdaptiveRenewal schedules each renewal from the lease remaining on the server instead of at a fixed interval:
+// after renewing, the leader reads TimeUntilExpiry and waits for fraction of it, less the time the renewal itself took,
+// so a slow database makes it renew more aggressively. The delay never drops below floor, and never exceeds the renew
+// interval. It costs an extra read per renewal. The fraction must be in (0, 1).
+func WithAdaptiveRenewal(fraction float64, floor time.Duration) Option {
+	return func(o *options) {
+		o.adaptiveFraction = fraction
+		o.adaptiveFloor = floor
+	}
+}
+
+// WithRetryClassifier sets how RunElection tells transient campaign and renewal errors, which it retries, from errors
+// that stop the election, e.g. for MySQL-compatible databases with error codes of their own. It can extend the default
+// by falling back to IsRetryable. Defaults to IsRetryable.
+func WithRetryClassifier(isRetryable func(err error) bool) Option {
+	return func(o *options) {
+		if isRetryable != nil {
+			o.isRetryable = isRetryable
+		}
+	}
+}
+
+// WithSafetyFactor sets how many renew intervals the lease must span at least: configurations renewing less often than
+// every lease/factor are rejected, as a leader that can barely renew in time flaps under any database latency. A
+// factor of 1 disables the check, only requiring the renew interval to be shorter than the lease. Defaults to 3.
+func WithSafetyFactor(factor float64) Option {
+	return func(o *options) {
+		o.safetyFactor = factor
+	}
+}
+
+// WithOnTransition registers a function RunElection calls with every leadership transition, the same events Subscribe
+// delivers. It runs on the election loop and must return quickly. Passed to NewManager, it observes the transitions of
+// all the Manager's elections.
+func WithOnTransition(fn func(event LeadershipEvent)) Option {
+	return func(o *options) {
+		o.onTransition = fn
+	}
+}
+
+// WithBackendBinding makes RunElection record the MySQL server (@@server_id and @@server_uuid) a lease is acquired on,
+// and check it after every renewal: a leader whose connection silently failed over to another server resigns and
+// steps down with LossReasonBackendChanged, as the lease timing it relied on may not hold there. It costs an extra
+// query per campaign and renewal, and requires a server with @@server_uuid (MySQL 5.6 or later).
+func WithBackendBinding() Option {
+	return func(o *options) {
+		o.bindBackend = true
+	}
+}
+
+// WithSQLBuilder replaces the statements elections acquire, renew, resign and read leases with. Defaults to
+// MySQLBuilder.
+func WithSQLBuilder(builder SQLBuilder) Option {
+	return func(o *options) {
+		if builder != nil {
+			o.sqlBuilder = builder
+		}
+	}
+}
+
+// WithCombinedVerify makes RunElection campaign and verify the result together instead of verifying with a separate
+// IsLeader query. When the DSN enables both multiStatements and interpolateParams, the upsert and the read-back are
+// sent as one multi-statement, so each attempt and renewal takes one round trip instead of two; otherwise they fall
+// back to one transaction, which costs the extra round trips of BEGIN and COMMIT but still reads back the lease
+// atomically.
+func WithCombinedVerify() Option {
+	return func(o *options) {
+		o.combinedVerify = true
+	}
+}
+
+// WithMinHoldDuration guarantees a newly elected leader the election for at least d: the acquisition records when
+// that period ends, and until then challengers treat the lease as taken even if renewals lapse, which prevents rapid
+// flapping. The trade-off is a slower failover for a leader that dies right after winning, which is only replaced once
+// both its lease and its minimum hold have run out. A resignation doesn't cut the hold short either. The period is
+// recorded by the candidate that acquires the lease, with whole-second precision. Disabled by default.
+func WithMinHoldDuration(d time.Duration) Option {
+	return func(o *options) {
+		o.minHold = d
+	}
+}
+
+// WithOnCampaign registers a function RunElection calls after every campaign and renewal, whatever its outcome, with
+// the time it took (for batched renewals, including the wait for the batch) and its error, if any. It runs on the
+// election loop, which holds no locks while calling it, but delays the next renewal, so it should return quickly.
+func WithOnCampaign(fn func(outcome Outcome, took time.Duration, err error)) Option {
+	return func(o *options) {
+		o.onCampaign = fn
+	}
+}
+
+// WithOnOperation registers a function called after each database round trip of a campaign, renewal, leadership check
+// or resignation, whoever issues it, with the operation (OperationCampaign, OperationRenew, OperationIsLeader or
+// OperationResign), the time it took and its error, if the query failed. A lost lease or campaign is not an error.
+// It runs on the calling goroutine, so it should return quickly.
+func WithOnOperation(fn func(op string, took time.Duration, err error)) Option {
+	return func(o *options) {
+		o.onOperation = fn
+	}
+}
+
+// WithLightweightRenewal makes RunElection renew a held lease with a plain update of its row, guarded by the term it
+// was won with, instead of re-running the campaign upsert, so only followers issue the upsert. This cuts the writes
+// on the shared row, and a lease taken over since is never renewed. Combined with WithCombinedVerify, only campaigns
+// are combined with their verification.
+func WithLightweightRenewal() Option {
+	return func(o *options) {
+		o.lightRenewal = true
+	}
+}
+
+// WithFileLockFallback lets RunElection lead by an exclusive lock on the file at path once the election database has
+// been unreachable for longer than after, so a single host keeps a leader through a database outage. This degraded
+// mode is only safe if every candidate runs on the same host, or shares path on a filesystem with working flock
+// semantics: a candidate that can't see the file can't see the lock either, so a candidate elsewhere can still lead
+// through the database, and two leaders may coexist. For the same reason, leading by the lock doesn't fence
+// anything written to the database. Degraded leadership is logged at error level when it starts and on every retry;
+// the lock is released as soon as the database answers again, and the candidate leads through the database if it
+// wins there and the term won passes WithFence and WithValidateLeadership, or steps down. Only supported on unix.
+// Disabled by default.
+func WithFileLockFallback(path string, after time.Duration) Option {
+	return func(o *options) {
+		o.fallbackPath = path
+		o.fallbackAfter = after
+	}
+}
+
+// validVariableName reports whether name can be used as a session variable name as is: the driver writes names into
+// its SET statement unquoted.
+func validVariableName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// validSchemaName reports whether name is a database name that can be used unquoted: MySQL identifier characters
+// only, and not all digits.
+func validSchemaName(name string) bool {
+	if name == "" || len(name) > 64 {
+		return false
+	}
+	digits := true
+	for _, r := range name {
+		switch {
+		case r >= '0' && r <= '9':
+		case r == '_' || r == '$' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z':
+			digits = false
+		default:
+			return false
+		}
+	}
+	return !digits
+}
+
+func hintPrefix(hint string) string {
+	if hint == "" {
+		return ""
+	}
+	return hint + " "
+}
+
+func (o *options) validate() error {
+	if o.maxRenewFailures < 1 {
+		return fmt.Errorf("%w: max renew failures must be at least 1, got %d", ErrInvalidConfig, o.maxRenewFailures)
+	}
+	if o.maxCampaigns < 1 {
+		return fmt.Errorf("%w: max concurrent campaigns must be at least 1, got %d", ErrInvalidConfig, o.maxCampaigns)
+	}
+	if o.epochLease && o.microseconds {
+		return fmt.Errorf("%w: epoch leases are timed in whole seconds, so they can't have microsecond precision",
+			ErrInvalidConfig)
+	}
+	minLease := time.Second
+	if o.microseconds {
+		minLease = time.Millisecond
+	}
+	if o.leaseDuration < minLease {
+		return fmt.Errorf("%w: lease duration must be at least %s, got %s", ErrInvalidConfig, minLease, o.leaseDuration)
+	}
+	if o.missedRenewals >= 0 {
+		if o.missedRenewals < 1 {
+			return fmt.Errorf("%w: at least 1 missed renewal must be tolerated, got %d", ErrInvalidConfig, o.missedRenewals)
+		}
+		if derived := o.renewInterval * time.Duration(o.missedRenewals+1); o.leaseDuration != derived {
+			return fmt.Errorf("%w: lease duration %s conflicts with %d missed renewals every %s (lease %s)",
+				ErrInvalidConfig, o.leaseDuration, o.missedRenewals, o.renewInterval, derived)
+		}
+		// the lease spans missed+1 renew intervals, which the safety factor bounds from below
+		if minMissed := int(math.Ceil(o.safetyFactor - 1)); o.missedRenewals < minMissed {
+			return fmt.Errorf("%w: %d missed renewals leave the lease no room for slow renewals, a safety factor of %g "+
+				"needs at least %d (see WithSafetyFactor)", ErrInvalidConfig, o.missedRenewals, o.safetyFactor, minMissed)
+		}
+	}
+	if o.renewInterval <= 0 || o.renewInterval >= o.leaseDuration {
+		return fmt.Errorf("%w: renew interval must be positive and shorter than the %s lease, got %s",
+			ErrInvalidConfig, o.leaseDuration, o.renewInterval)
+	}
+	if o.safetyFactor < 1 {
+		return fmt.Errorf("%w: safety factor must be at least 1, got %g", ErrInvalidConfig, o.safetyFactor)
+	}
+	if maxRenew := time.Duration(float64(o.leaseDuration) / o.safetyFactor); o.renewInterval > maxRenew {
+		return fmt.Errorf("%w: a %s renew interval leaves the %s lease no room for slow renewals, it must be at most "+
+			"lease/%g: renew at most every %s, or use a lease of at least %s (see WithSafetyFactor)",
+			ErrInvalidConfig, o.renewInterval, o.leaseDuration, o.safetyFactor, maxRenew,
+			time.Duration(float64(o.renewInterval)*o.safetyFactor))
+	}
+	if o.queryTimeout <= 0 || o.queryTimeout > o.renewInterval {
+		return fmt.Errorf("%w: query timeout must be positive and at most the %s renew interval, got %s",
+			ErrInvalidConfig, o.renewInterval, o.queryTimeout)
+	}
+	if o.acquireTimeout < 0 || o.acquireTimeout > o.queryTimeout {
+		return fmt.Errorf("%w: acquire timeout must be at most the %s query timeout, got %s",
+			ErrInvalidConfig, o.queryTimeout, o.acquireTimeout)
+	}
+	if o.shutdownGrace < 0 {
+		return fmt.Errorf("%w: shutdown grace can't be negative, got %s", ErrInvalidConfig, o.shutdownGrace)
+	}
+	if o.minHold < 0 {
+		return fmt.Errorf("%w: minimum hold duration can't be negative, got %s", ErrInvalidConfig, o.minHold)
+	}
+	if o.fallbackPath != "" && o.fallbackAfter <= 0 {
+		return fmt.Errorf("%w: file lock fallback threshold must be positive, got %s", ErrInvalidConfig, o.fallbackAfter)
+	}
+	if o.schema != "" && !validSchemaName(o.schema) {
+		return fmt.Errorf("%w: invalid schema name %q", ErrInvalidConfig, o.schema)
+	}
+	for name := range o.sessionVars {
+		if !validVariableName(name) {
+			return fmt.Errorf("%w: invalid session variable name %q", ErrInvalidConfig, name)
+		}
+	}
+	if o.longLeadership < 0 {
+		return fmt.Errorf("%w: long leadership threshold can't be negative, got %s", ErrInvalidConfig, o.longLeadership)
+	}
+	if o.skewTolerance < 0 {
+		return fmt.Errorf("%w: clock skew tolerance can't be negative, got %s", ErrInvalidConfig, o.skewTolerance)
+	}
+	if o.expiryJitter < 0 || o.expiryJitter > o.leaseDuration/2 {
+		return fmt.Errorf("%w: expiry jitter must be between 0 and half the lease duration (%s), got %s",
+			ErrInvalidConfig, o.leaseDuration/2, o.expiryJitter)
+	}
+	if o.stealBackoff < 0 {
+		return fmt.Errorf("%w: steal backoff can't be negative, got %s", ErrInvalidConfig, o.stealBackoff)
+	}
+	if o.postLossCooldown < 0 {
+		return fmt.Errorf("%w: post-loss cooldown can't be negative, got %s", ErrInvalidConfig, o.postLossCooldown)
+	}
+	if o.fairnessDelay < 0 {
+		return fmt.Errorf("%w: fairness delay can't be negative, got %s", ErrInvalidConfig, o.fairnessDelay)
+	}
+	if o.zoneGrace < 0 {
+		return fmt.Errorf("%w: zone grace can't be negative, got %s", ErrInvalidConfig, o.zoneGrace)
+	}
+	if o.yieldGrace < 0 {
+		return fmt.Errorf("%w: yield grace can't be negative, got %s", ErrInvalidConfig, o.yieldGrace)
+	}
+	if o.adaptiveFraction != 0 {
+		if o.adaptiveFraction < 0 || o.adaptiveFraction >= 1 {
+			return fmt.Errorf("%w: adaptive renewal fraction must be between 0 and 1, got %g", ErrInvalidConfig, o.adaptiveFraction)
+		}
+		if o.adaptiveFloor <= 0 || o.adaptiveFloor > o.renewInterval {
+			return fmt.Errorf("%w: adaptive renewal floor must be positive and at most the %s renew interval, got %s",
+				ErrInvalidConfig, o.renewInterval, o.adaptiveFloor)
+		}
+	}
+	return nil
+}
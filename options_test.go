@@ -0,0 +1,39 @@
+package leaderelection
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMissedRenewalsAndSafetyFactor(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		opts    []Option
+		wantErr string
+	}{
+		{name: "default factor", opts: []Option{WithMissedRenewals(10*time.Second, 2)}},
+		{name: "default factor, 1 missed", opts: []Option{WithMissedRenewals(10*time.Second, 1)},
+			wantErr: "a safety factor of 3 needs at least 2"},
+		{name: "factor 1, 1 missed", opts: []Option{WithMissedRenewals(10*time.Second, 1), WithSafetyFactor(1)}},
+		{name: "factor 2.5, 1 missed", opts: []Option{WithMissedRenewals(10*time.Second, 1), WithSafetyFactor(2.5)},
+			wantErr: "a safety factor of 2.5 needs at least 2"},
+		{name: "no missed renewals", opts: []Option{WithMissedRenewals(10*time.Second, 0), WithSafetyFactor(1)},
+			wantErr: "at least 1 missed renewal"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o := newOptions(tc.opts)
+			err := o.validate()
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("validate() = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidConfig) || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("validate() = %v, want ErrInvalidConfig mentioning %q", err, tc.wantErr)
+			}
+		})
+	}
+}
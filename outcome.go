@@ -0,0 +1,22 @@
+package leaderelection
+
+// Outcome classifies the result of one iteration of the election loop. It is reported under LogKeyOutcome and passed
+// to the BackoffStrategy.
+type Outcome string
+
+const (
+	// OutcomeWon means the candidate acquired leadership.
+	OutcomeWon Outcome = "won"
+	// OutcomeRenewed means the leader renewed its lease.
+	OutcomeRenewed Outcome = "renewed"
+	// OutcomeLost means the leader lost its leadership.
+	OutcomeLost Outcome = "lost"
+	// OutcomeResigned means the leader gave up its lease.
+	OutcomeResigned Outcome = "resigned"
+	// OutcomeNotAcquired means another candidate holds a valid lease.
+	OutcomeNotAcquired Outcome = "not_acquired"
+	// OutcomeUnverified means a campaign appeared to win, but leadership could not be verified.
+	OutcomeUnverified Outcome = "unverified"
+	// OutcomeError means the database could not be queried.
+	OutcomeError Outcome = "error"
+)
@@ -0,0 +1,148 @@
+package leaderelection
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"gorm.io/gorm"
+)
+
+// uniqueIndexName is the unique index on election_name that makes concurrent campaigns converge on one row.
+const uniqueIndexName = "uidx_election_name"
+
+// RepairReport describes what Repair found, and unless it was a dry run, fixed.
+type RepairReport struct {
+	// Duplicates maps each stored election name with more than one row to its number of rows. All but one of them
+	// are removed.
+	Duplicates map[string]int
+	// IndexMissing reports whether the unique index on election_name was missing. It is created once the duplicates
+	// are gone.
+	IndexMissing bool
+	// ColumnsMissing lists the columns of ElectionRecord the table lacked, such as term in tables created by the
+	// earliest versions. They are added before the duplicates are collapsed.
+	ColumnsMissing []string
+}
+
+// Repair fixes an election table that lacks the unique index on election_name, as created by versions whose index tag
+// didn't take effect: without it, candidates can insert duplicate rows for one election, the campaign upsert no longer
+// converges and leadership becomes ambiguous. Repair collapses every election's rows to the one updated last, keeping
+// the highest term among them so terms keep increasing, and then creates the index. It covers all elections in the
+// table, not just e's. With dryRun it only reports what it would change.
+//
+// AutoMigrate fails to create the index while duplicates exist, so create the election used for the repair
+// WithoutAutoMigrate, and stop the candidates of the affected elections while repairing. Repair adds the columns such
+// an early table lacks itself. Each of its statements is bounded by the query timeout.
+func (e *Election) Repair(ctx context.Context, dryRun bool) (RepairReport, error) {
+	ctx = orBackground(ctx)
+	if err := e.checkOpen(); err != nil {
+		return RepairReport{}, err
+	}
+	if e.memory != nil {
+		return RepairReport{}, errMemoryUnsupported("Repair")
+	}
+	report := RepairReport{Duplicates: make(map[string]int)}
+	indexCtx, cancel := e.queryContext(ctx)
+	report.IndexMissing = !e.tableDB(indexCtx, "{records}").Migrator().HasIndex(&ElectionRecord{}, uniqueIndexName)
+	cancel()
+	var err error
+	if report.ColumnsMissing, err = e.missingColumns(ctx); err != nil {
+		return report, fmt.Errorf("failed to look for missing columns: %w", err)
+	}
+	var duplicates []struct {
+		ElectionName string
+		RowCount     int
+	}
+	err = e.repairStep(ctx, func(ctx context.Context) error {
+		sql := `SELECT election_name, COUNT(*) AS row_count FROM {records} GROUP BY election_name HAVING COUNT(*) > 1`
+		return e.db.WithContext(ctx).Raw(e.writeSQL(sql)).Scan(&duplicates).Error
+	})
+	if err != nil {
+		return report, fmt.Errorf("failed to look for duplicate rows: %w", err)
+	}
+	for _, d := range duplicates {
+		report.Duplicates[d.ElectionName] = d.RowCount
+	}
+	if dryRun {
+		return report, nil
+	}
+
+	for _, column := range report.ColumnsMissing {
+		err := e.repairStep(ctx, func(ctx context.Context) error {
+			err := e.tableDB(ctx, "{records}").Migrator().AddColumn(&ElectionRecord{}, column)
+			if alreadyExists(err) {
+				return nil
+			}
+			return err
+		})
+		if err != nil {
+			return report, fmt.Errorf("failed to add the missing column %s: %w", column, err)
+		}
+		e.logger.Warn("added a missing column to the election table", slog.String("column", column))
+	}
+	for _, d := range duplicates {
+		err := e.repairStep(ctx, func(ctx context.Context) error {
+			return e.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+				return e.collapse(tx, d.ElectionName)
+			})
+		})
+		if err != nil {
+			return report, fmt.Errorf("failed to collapse the rows of election %q: %w", d.ElectionName, err)
+		}
+		e.logger.Warn("collapsed duplicate election rows", slog.String("stored_name", d.ElectionName),
+			slog.Int("rows", d.RowCount))
+	}
+	if report.IndexMissing {
+		err := e.repairStep(ctx, func(ctx context.Context) error {
+			return e.tableDB(ctx, "{records}").Migrator().CreateIndex(&ElectionRecord{}, uniqueIndexName)
+		})
+		if err != nil {
+			return report, fmt.Errorf("failed to create the unique index: %w", err)
+		}
+		e.logger.Warn("created the missing unique index on election_name")
+	}
+	return report, nil
+}
+
+// repairStep runs one step of Repair bounded by the query timeout.
+func (e *Election) repairStep(ctx context.Context, step func(ctx context.Context) error) error {
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	return ctxError(ctx, step(ctx))
+}
+
+// missingColumns returns the columns of ElectionRecord the election table lacks.
+func (e *Election) missingColumns(ctx context.Context) ([]string, error) {
+	stmt := &gorm.Statement{DB: e.db}
+	if err := stmt.Parse(&ElectionRecord{}); err != nil {
+		return nil, err
+	}
+	var missing []string
+	for _, column := range stmt.Schema.DBNames {
+		columnCtx, cancel := e.queryContext(ctx)
+		found := e.tableDB(columnCtx, "{records}").Migrator().HasColumn(&ElectionRecord{}, column)
+		cancel()
+		if !found {
+			missing = append(missing, column)
+		}
+	}
+	return missing, ctx.Err()
+}
+
+// collapse deletes all rows of the stored election name but the one updated last, which is given the highest term of
+// them all.
+func (e *Election) collapse(tx *gorm.DB, storedName string) error {
+	var keep struct {
+		ID   uint
+		Term uint64
+	}
+	sql := `SELECT id, (SELECT MAX(term) FROM {records} WHERE election_name=?) AS term FROM {records}
+			WHERE election_name=? ORDER BY last_update DESC, id DESC LIMIT 1 FOR UPDATE`
+	if err := tx.Raw(e.writeSQL(sql), storedName, storedName).Scan(&keep).Error; err != nil {
+		return err
+	}
+	if err := tx.Exec(e.writeSQL(`UPDATE {records} SET term = ? WHERE id = ?`), keep.Term, keep.ID).Error; err != nil {
+		return err
+	}
+	return tx.Exec(e.writeSQL(`DELETE FROM {records} WHERE election_name=? and id <> ?`), storedName, keep.ID).Error
+}
@@ -0,0 +1,656 @@
+package leaderelection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"math/bits"
+	"math/rand/v2"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+type CallbackFunc func()
+
+// ContextCallbackFunc is a leadership callback that receives the values of the context the election runs with, such
+// as trace IDs, so they can flow into leader-only work. The context is never cancelled: the lose callback is the
+// signal to stop.
+type ContextCallbackFunc func(ctx context.Context)
+
+// ElectLeader runs RunElection in the background context, logging the error if the election ever stops. Use
+// RunElectionContext for callbacks that receive the values of a context of your own.
+func ElectLeader(electionName string, becomeLeaderCb CallbackFunc, looseLeadershipCB CallbackFunc, opts ...Option) {
+	if err := RunElection(context.Background(), electionName, becomeLeaderCb, looseLeadershipCB, opts...); err != nil {
+		newOptions(opts).logger.Error("election stopped", slog.String(LogKeyElection, electionName), slog.Any("error", err))
+	}
+}
+
+// RunElection participates in the election until ctx is done or the election fails, invoking becomeLeaderCb when
+// this process wins leadership and looseLeadershipCB when it loses it (including when the election stops while
+// leading).
+//
+// Callbacks run one at a time on a goroutine of their own, so a slow callback doesn't hold up renewals. They always
+// alternate between become and lose; transitions that happen while a callback is still running are coalesced into the
+// latest state. When it stops while leading, RunElection cancels the leader work, waits up to the shutdown grace for it
+// to return, and resigns, so another candidate can take over without waiting for the lease to expire. It waits for the
+// final lose callback to return before returning itself.
+//
+// The returned error tells supervisors why the election stopped: the context error when ctx is done, an error wrapping
+// ErrInvalidConfig when the options or configuration are unusable, or an error wrapping ErrNotConnected when the
+// database can't be reached, or fails a query with an error the retry classifier (IsRetryable by default) doesn't
+// consider transient. Transient errors are retried: by followers after backing off, and by leaders up to the maximum
+// number of renew failures. A nil ctx is treated as context.Background(), as it is by every method taking a context.
+func RunElection(ctx context.Context, electionName string, becomeLeaderCb CallbackFunc, looseLeadershipCB CallbackFunc, opts ...Option) error {
+	election, err := newRunElection(electionName, opts)
+	if err != nil {
+		return err
+	}
+	return election.Run(ctx, becomeLeaderCb, looseLeadershipCB)
+}
+
+// RunElectionContext is RunElection with callbacks that receive the values of ctx.
+func RunElectionContext(ctx context.Context, electionName string, becomeLeaderCb ContextCallbackFunc, looseLeadershipCB ContextCallbackFunc, opts ...Option) error {
+	election, err := newRunElection(electionName, opts)
+	if err != nil {
+		return err
+	}
+	return election.RunContext(ctx, becomeLeaderCb, looseLeadershipCB)
+}
+
+// newRunElection creates the election RunElection runs, naming the candidate through the identity provider and
+// connecting with the .env configuration.
+func newRunElection(electionName string, opts []Option) (*Election, error) {
+	o := newOptions(opts)
+	workerName, err := identify(o.identity, o.logger)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to determine candidate identity: %w", ErrInvalidConfig, err)
+	}
+	appConfig, err := godotenv.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: error reading .env file: %w", ErrInvalidConfig, err)
+	}
+
+	election, err := NewElection(electionName, workerName, appConfig, opts...)
+	if err != nil {
+		if errors.Is(err, ErrInvalidConfig) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %w", ErrNotConnected, err)
+	}
+	return election, nil
+}
+
+// Run participates in the election as e's candidate until ctx is done or the election fails, the same way RunElection
+// does for an election it creates itself. An election must only be run by one loop at a time.
+func (e *Election) Run(ctx context.Context, becomeLeaderCb CallbackFunc, looseLeadershipCB CallbackFunc) error {
+	return e.RunContext(ctx, func(context.Context) { becomeLeaderCb() }, func(context.Context) { looseLeadershipCB() })
+}
+
+// RunContext is Run with callbacks that receive the values of ctx.
+func (e *Election) RunContext(ctx context.Context, becomeLeaderCb ContextCallbackFunc, looseLeadershipCB ContextCallbackFunc) error {
+	ctx = orBackground(ctx)
+	if err := e.checkOpen(); err != nil {
+		return err
+	}
+	// running the election again is the explicit restart a resigned candidate needs
+	e.Rejoin()
+	var reserved *reservedConn
+	if e.opts.dedicatedConn && e.memory == nil {
+		var err error
+		if reserved, err = e.reserveConn(ctx); err != nil {
+			return stopError(ctx, err)
+		}
+		defer reserved.release()
+	}
+	register := e.opts.registerCandidate && e.memory == nil
+	if register {
+		defer func() {
+			if e.checkOpen() == nil {
+				e.deregister(context.WithoutCancel(ctx))
+			}
+		}()
+	}
+	onStopped := e.opts.onStoppedLeading
+	callbacks := newCallbackQueue(ctx, e.opts.executor, becomeLeaderCb, func(ctx context.Context, reason LossReason) {
+		looseLeadershipCB(ctx)
+		if onStopped != nil {
+			onStopped(reason)
+		}
+	})
+	defer callbacks.close()
+	isLeader := false
+	var heldTerm uint64
+	var heldBackend string
+	var work *leaderWork
+	var lastLed time.Time
+	// ledSince is when leadership was won, for the long leadership watchdog, and warnedLong whether it fired since
+	var ledSince time.Time
+	var warnedLong bool
+	metrics := countingMetrics{Metrics: e.opts.metrics, counters: &e.counters}
+	fallback := e.newFallbackLock()
+	defer fallback.release()
+	lead := func() {
+		isLeader = true
+		e.newLeadershipToken()
+		e.leading.Store(true)
+		ledSince, warnedLong = time.Now(), false
+		metrics.SetLeading(e.name, true)
+		e.transition(e.newEvent(true, ""))
+		callbacks.set(true, "")
+		if e.opts.onStartedLeading != nil {
+			work = startLeaderWork(ctx, e.opts.executor, e.opts.onStartedLeading)
+		}
+	}
+	stepDown := func(level slog.Level, msg string, reason LossReason, args ...any) {
+		isLeader = false
+		e.leading.Store(false)
+		e.token.Store(nil)
+		lastLed = time.Now()
+		fallback.release()
+		e.renewedAt.Store(0)
+		metrics.SetLeading(e.name, false)
+		metrics.SetLeaseAge(e.name, 0)
+		if work != nil {
+			work.cancel()
+			work = nil
+		}
+		e.logEvent(ctx, level, msg, OutcomeLost, append([]any{slog.String(LogKeyReason, string(reason))}, args...)...)
+		e.transition(e.newEvent(false, reason))
+		callbacks.set(false, reason)
+		e.publishStatus(ctx, false)
+	}
+	defer func() {
+		if !isLeader {
+			return
+		}
+		if work != nil && !work.stop(e.opts.shutdownGrace) {
+			e.logEvent(ctx, slog.LevelWarn, "leader work did not finish within the shutdown grace period", OutcomeLost,
+				slog.Duration("grace", e.opts.shutdownGrace))
+		}
+		if e.checkOpen() == nil {
+			if err := e.resign(context.WithoutCancel(ctx)); err != nil {
+				e.logEvent(ctx, slog.LevelError, "failed to resign", OutcomeError, slog.Any("error", err))
+			}
+		}
+		stepDown(slog.LevelInfo, "election stopped while leading", LossReasonStopped)
+	}()
+
+	e.logger.Info("starting as candidate")
+	renewFailures := 0
+	attempts := 0
+	for {
+		if err := e.checkOpen(); err != nil {
+			return err
+		}
+		outcome := OutcomeNotAcquired
+		if isLeader {
+			metrics.SetLeaseAge(e.name, e.LeaseAge())
+		} else {
+			if err := e.coolDown(ctx, lastLed); err != nil {
+				return err
+			}
+			if err := e.yieldFreeLease(ctx, lastLed); err != nil {
+				return err
+			}
+		}
+		if register {
+			e.heartbeat(ctx)
+		}
+		var wonCampaign bool
+		var err error
+		var latency time.Duration
+		attemptStarted := time.Now()
+		batched := isLeader && e.batch != nil && !fallback.held()
+		release := func() {}
+		if !batched {
+			if release, err = e.campaigns.acquire(ctx); err != nil {
+				return err
+			}
+		}
+		if batched {
+			// the batch renews on its own schedule, which stands in for the renew interval; a renewal can only succeed
+			// while the lease is held throughout, so it needs no verification
+			if wonCampaign, err = e.batch.renew(ctx, e); err != nil {
+				err = fmt.Errorf("batched renewal failed: %w", err)
+			}
+		} else if isLeader && e.opts.lightRenewal && !fallback.held() {
+			// a plain update of the held lease, under the term it was won with, so superseded leases aren't renewed
+			started := time.Now()
+			err = e.RenewAndVerifyTerm(ctx, heldTerm)
+			latency = time.Since(started)
+			metrics.ObserveRenewLatency(e.name, latency)
+			wonCampaign = err == nil
+			if errors.Is(err, ErrLeaseLost) {
+				err = nil
+			} else if err != nil {
+				err = fmt.Errorf("renewal failed: %w", err)
+			}
+		} else if e.opts.combinedVerify {
+			// the campaign reports the lease it leaves behind, so it needs no separate verification
+			started := time.Now()
+			wonCampaign, err = e.campaignAndVerify(ctx)
+			latency = time.Since(started)
+			if isLeader {
+				metrics.ObserveRenewLatency(e.name, latency)
+			}
+			if err != nil {
+				err = fmt.Errorf("campaign failed: %w", err)
+			}
+		} else {
+			started := time.Now()
+			wonCampaign, err = e.campaignRound(ctx)
+			latency = time.Since(started)
+			if isLeader {
+				metrics.ObserveRenewLatency(e.name, latency)
+			}
+			if err == nil && wonCampaign {
+				//double check.
+				var verifyLeadership bool
+				if verifyLeadership, err = e.IsLeader(ctx); err != nil {
+					err = fmt.Errorf("leadership verification failed: %w", err)
+				} else if !verifyLeadership {
+					e.logEvent(ctx, slog.LevelWarn, "failed to verify leadership, will reattempt", OutcomeUnverified)
+					metrics.IncCampaigns(e.name, OutcomeUnverified)
+					e.observeCampaign(OutcomeUnverified, time.Since(attemptStarted), nil)
+					release()
+					continue
+				}
+			} else if err != nil {
+				err = fmt.Errorf("campaign failed: %w", err)
+			}
+		}
+		release()
+		if !isLeader && errors.Is(err, errAcquireBlocked) {
+			// blocked behind other campaigns on the row lock: not acquired this round. A leader counts it as a failed
+			// renewal instead, as its lease is still valid.
+			err = nil
+		}
+		var backend string
+		if err == nil && wonCampaign && e.opts.bindBackend && e.memory == nil {
+			if backend, err = e.backendID(ctx); err != nil {
+				err = fmt.Errorf("failed to identify the database server: %w", err)
+			}
+		}
+		e.observeCampaign(campaignOutcome(isLeader, wonCampaign, err), time.Since(attemptStarted), err)
+
+		if err != nil {
+			metrics.IncCampaigns(e.name, OutcomeError)
+			if errors.Is(err, ErrResigned) && ctx.Err() == nil {
+				e.logEvent(ctx, slog.LevelInfo, "candidate resigned, stopping the election", OutcomeResigned)
+				return ErrResigned
+			}
+			if errors.Is(err, ErrElectionClosed) && ctx.Err() == nil {
+				e.logEvent(ctx, slog.LevelInfo, "election closed, stopping the election", OutcomeError)
+				return ErrElectionClosed
+			}
+			if ctx.Err() != nil || (!errors.Is(err, errAcquireBlocked) && !e.opts.isRetryable(err)) {
+				e.logEvent(ctx, slog.LevelError, "election failed", OutcomeError, slog.Any("error", err))
+				return stopError(ctx, err)
+			}
+			if reserved != nil && connectionLost(err) {
+				// the dedicated connection is gone for good: retry on a fresh one
+				if resetErr := reserved.reset(ctx); resetErr != nil {
+					e.logEvent(ctx, slog.LevelWarn, "failed to replace the dedicated connection", OutcomeError,
+						slog.Any("error", resetErr))
+				} else {
+					e.logEvent(ctx, slog.LevelWarn, "dedicated connection lost, replaced it", OutcomeError,
+						slog.Any("error", err))
+				}
+			}
+			fallback.failed()
+			if fallback.held() {
+				// degraded: the file lock is kept until the database answers again
+				e.logEvent(ctx, slog.LevelError, "election database still unreachable, leading by file lock",
+					OutcomeError, slog.String("path", fallback.path), slog.Any("error", err))
+				if err = sleep(ctx, e.opts.renewInterval); err != nil {
+					return err
+				}
+				continue
+			}
+			if !isLeader && fallback.due() {
+				if lockErr := fallback.acquire(); lockErr != nil {
+					e.logEvent(ctx, slog.LevelWarn, "failed to take the fallback file lock", OutcomeError,
+						slog.String("path", fallback.path), slog.Any("error", lockErr))
+				} else {
+					e.logEvent(ctx, slog.LevelError, "election database unreachable, leading by file lock in degraded mode",
+						OutcomeWon, slog.String("path", fallback.path), slog.Duration("unreachable_for", time.Since(fallback.since)),
+						slog.Any("error", err))
+					metrics.IncCampaigns(e.name, OutcomeWon)
+					lead()
+					if err = sleep(ctx, e.opts.renewInterval); err != nil {
+						return err
+					}
+					continue
+				}
+			}
+			if !isLeader {
+				attempts++
+				e.logEvent(ctx, slog.LevelWarn, "campaign failed, will retry", OutcomeError,
+					slog.Int("attempt", attempts), slog.Any("error", err))
+				if err = sleep(ctx, e.opts.backoff.NextInterval(attempts, OutcomeError)); err != nil {
+					return err
+				}
+				continue
+			}
+			// a leader rides out transient failures while its lease lasts
+			renewFailures++
+			if renewFailures < e.opts.maxRenewFailures {
+				e.logEvent(ctx, slog.LevelWarn, "failed to renew leadership, will retry", OutcomeError,
+					slog.Int("failures", renewFailures), slog.Any("error", err))
+				if batched {
+					continue
+				}
+				if err = sleep(ctx, e.opts.renewInterval); err != nil {
+					return err
+				}
+				continue
+			}
+			stepDown(slog.LevelError, "too many consecutive renewal failures, stepping down", LossReasonRenewFailures,
+				slog.Int("failures", renewFailures), slog.Any("error", err))
+			outcome = OutcomeError
+			wonCampaign = false
+		}
+		renewFailures = 0
+		fallback.reached()
+		if fallback.held() {
+			// the database answers again: lead through it if it agrees, or step down below. The term it granted is
+			// admitted like any other acquisition first, as the lock fenced nothing.
+			fallback.release()
+			if wonCampaign {
+				heldTerm = e.Term()
+				heldBackend = backend
+				if msg, err := e.admit(ctx); err != nil {
+					metrics.IncCampaigns(e.name, OutcomeResigned)
+					if err := e.resign(ctx); err != nil {
+						e.logEvent(ctx, slog.LevelError, "failed to resign", OutcomeError, slog.Any("error", err))
+					}
+					stepDown(slog.LevelWarn, msg, LossReasonLeaseLost, slog.Any("error", err))
+					attempts++
+					if err := sleep(ctx, e.opts.backoff.NextInterval(attempts, OutcomeResigned)); err != nil {
+						return err
+					}
+					continue
+				}
+				e.stampCorrelation(ctx, heldTerm)
+				e.logEvent(ctx, slog.LevelWarn, "election database reachable again, leaving degraded mode", OutcomeRenewed)
+			}
+		}
+
+		if !wonCampaign {
+			if isLeader {
+				outcome = OutcomeLost
+				stepDown(slog.LevelWarn, "lost leadership", LossReasonLeaseLost)
+			} else {
+				e.publishStatus(ctx, false)
+			}
+			if outcome != OutcomeError {
+				metrics.IncCampaigns(e.name, outcome)
+			}
+			attempts++
+			e.logEvent(ctx, slog.LevelDebug, "failed to acquire leadership, will reattempt", OutcomeNotAcquired,
+				slog.Int("attempt", attempts))
+			if err = sleep(ctx, e.opts.backoff.NextInterval(attempts, outcome)); err != nil {
+				return err
+			}
+			continue
+		}
+		attempts = 0
+
+		if isLeader && e.Term() != heldTerm {
+			// our name is on the row, but under another term: the lease lapsed and was taken over since we acquired
+			// it, so whoever led in between may have acted as leader too
+			stepDown(slog.LevelError, "superseded while leading, stepping down", LossReasonSuperseded,
+				slog.Uint64("held_term", heldTerm))
+			metrics.IncCampaigns(e.name, OutcomeLost)
+			continue
+		}
+		if isLeader && backend != heldBackend {
+			// the lease was renewed on another server than it was acquired on, e.g. after a silent failover, so the
+			// timing it was held with so far can't be trusted
+			if err := e.resign(ctx); err != nil {
+				e.logEvent(ctx, slog.LevelError, "failed to resign", OutcomeError, slog.Any("error", err))
+			}
+			stepDown(slog.LevelError, "database server changed while leading, stepping down", LossReasonBackendChanged,
+				slog.String("held_backend", heldBackend), slog.String("backend", backend))
+			metrics.IncCampaigns(e.name, OutcomeLost)
+			attempts++
+			if err := sleep(ctx, e.opts.backoff.NextInterval(attempts, OutcomeLost)); err != nil {
+				return err
+			}
+			continue
+		}
+		if !isLeader {
+			if msg, err := e.admit(ctx); err != nil {
+				e.logEvent(ctx, slog.LevelWarn, msg, OutcomeResigned, slog.Any("error", err))
+				metrics.IncCampaigns(e.name, OutcomeResigned)
+				if err := e.resign(ctx); err != nil {
+					e.logEvent(ctx, slog.LevelError, "failed to resign", OutcomeError, slog.Any("error", err))
+				}
+				attempts++
+				if err := sleep(ctx, e.opts.backoff.NextInterval(attempts, OutcomeResigned)); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		e.renewedAt.Store(time.Now().UnixNano())
+		if !isLeader {
+			heldTerm = e.Term()
+			heldBackend = backend
+			e.logEvent(ctx, slog.LevelInfo, "won the election and is the leader", OutcomeWon)
+			metrics.IncCampaigns(e.name, OutcomeWon)
+			e.stampCorrelation(ctx, heldTerm)
+			lead()
+		} else {
+			e.logEvent(ctx, slog.LevelDebug, "renewed leadership", OutcomeRenewed)
+			metrics.IncCampaigns(e.name, OutcomeRenewed)
+			if threshold := e.opts.longLeadership; threshold > 0 && !warnedLong && time.Since(ledSince) >= threshold {
+				warnedLong = e.warnLongLeadership(ctx, time.Since(ledSince))
+			}
+		}
+		e.publishStatus(ctx, true)
+		if e.batch != nil {
+			continue
+		}
+		if err = sleep(ctx, e.renewDelay(ctx, latency)); err != nil {
+			return err
+		}
+	}
+}
+
+// leaderWork runs the OnStartedLeading function for the duration of one leadership.
+type leaderWork struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func startLeaderWork(ctx context.Context, exec CallbackExecutor, fn func(ctx context.Context)) *leaderWork {
+	ctx, cancel := context.WithCancel(ctx)
+	w := &leaderWork{cancel: cancel, done: make(chan struct{})}
+	exec(func() {
+		defer close(w.done)
+		fn(ctx)
+	})
+	return w
+}
+
+// stop cancels the work and waits up to grace for it to return, reporting whether it did.
+func (w *leaderWork) stop(grace time.Duration) bool {
+	w.cancel()
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
+	select {
+	case <-w.done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// observeCampaign reports a campaign to the WithOnCampaign hook.
+func (e *Election) observeCampaign(outcome Outcome, took time.Duration, err error) {
+	if e.opts.onCampaign != nil {
+		e.opts.onCampaign(outcome, took, err)
+	}
+}
+
+// campaignOutcome classifies the result of a campaign by a candidate that was leading or not before it.
+func campaignOutcome(wasLeader bool, won bool, err error) Outcome {
+	switch {
+	case err != nil:
+		return OutcomeError
+	case won && wasLeader:
+		return OutcomeRenewed
+	case won:
+		return OutcomeWon
+	case wasLeader:
+		return OutcomeLost
+	default:
+		return OutcomeNotAcquired
+	}
+}
+
+// renewDelay returns how long a leader waits before renewing again: the renew interval, or with adaptive renewal the
+// configured fraction of the lease remaining on the server, less the time the last renewal took, between the floor and
+// the renew interval.
+func (e *Election) renewDelay(ctx context.Context, latency time.Duration) time.Duration {
+	if e.opts.adaptiveFraction == 0 {
+		return e.opts.renewInterval
+	}
+	remaining, err := e.TimeUntilExpiry(ctx)
+	if errors.Is(err, ErrLeaseLost) {
+		// renew as soon as allowed to find out for sure
+		return e.opts.adaptiveFloor
+	}
+	if err != nil {
+		e.logEvent(ctx, slog.LevelWarn, "failed to read the remaining lease, renewing at the regular interval", OutcomeError,
+			slog.Any("error", err))
+		return e.opts.renewInterval
+	}
+	delay := time.Duration(float64(remaining)*e.opts.adaptiveFraction) - latency
+	return min(max(delay, e.opts.adaptiveFloor), e.opts.renewInterval)
+}
+
+// warnLongLeadership reports leadership held for held, beyond the WithOnLongLeadership threshold, unless other
+// registered candidates are seen participating. It reports whether it warned, so it isn't repeated this tenure.
+func (e *Election) warnLongLeadership(ctx context.Context, held time.Duration) bool {
+	if e.opts.registerCandidate && e.memory == nil {
+		candidates, err := e.Candidates(ctx)
+		if err != nil {
+			e.logEvent(ctx, slog.LevelWarn, "failed to list candidates", OutcomeError, slog.Any("error", err))
+			return false
+		}
+		if len(candidates) > 1 {
+			return false
+		}
+	}
+	e.logEvent(ctx, slog.LevelWarn, "leadership held for suspiciously long, other candidates may not be running",
+		OutcomeRenewed, slog.Duration("held", held))
+	if e.opts.onLongLeadership != nil {
+		e.opts.onLongLeadership(held)
+	}
+	return true
+}
+
+// yieldFreeLease gives other candidates the first chance at a free lease: a candidate that finds no valid lease waits
+// out the yield grace before campaigning for it unless it is the preferred candidate, or the zone grace unless it is
+// in the preferred zone (see WithZoneAffinity), one that stopped leading at
+// lastLed less than a lease ago waits for the fairness delay (see WithFairness), and with WithStealBackoff, every
+// candidate waits for its steal delay. It only reports an error when ctx is done.
+func (e *Election) yieldFreeLease(ctx context.Context, lastLed time.Time) error {
+	var wait time.Duration
+	if e.opts.preferredLeader != "" && e.opts.preferredLeader != e.candidate {
+		wait = e.opts.yieldGrace
+	}
+	if e.opts.preferredZone != "" && e.opts.zone != e.opts.preferredZone {
+		wait = max(wait, e.opts.zoneGrace)
+	}
+	if e.opts.fairnessDelay > 0 && !lastLed.IsZero() && time.Since(lastLed) < e.opts.leaseDuration {
+		// a random share of the delay breaks ties between several recent leaders
+		wait = max(wait, e.opts.fairnessDelay/2+rand.N(e.opts.fairnessDelay/2+1))
+	}
+	if e.opts.stealBackoff > 0 {
+		wait = max(wait, e.stealDelay())
+	}
+	if wait == 0 {
+		return nil
+	}
+	if _, err := e.GetLeader(ctx); !errors.Is(err, ErrNoLeader) {
+		// the lease is held, or its state is unknown; campaigning straight away finds out which
+		return nil
+	}
+	e.logEvent(ctx, slog.LevelDebug, "lease is free, yielding to other candidates", OutcomeNotAcquired,
+		slog.String("preferred", e.opts.preferredLeader), slog.Duration("wait", wait))
+	return sleep(ctx, wait)
+}
+
+// coolDown holds off campaigning until the post-loss cooldown since leadership was last lost has passed, giving the
+// new leader a chance to settle, see WithPostLossCooldown.
+func (e *Election) coolDown(ctx context.Context, lastLed time.Time) error {
+	if e.opts.postLossCooldown <= 0 || lastLed.IsZero() {
+		return nil
+	}
+	remaining := e.opts.postLossCooldown - time.Since(lastLed)
+	if remaining <= 0 {
+		return nil
+	}
+	e.logEvent(ctx, slog.LevelDebug, "lost leadership recently, cooling down before campaigning", OutcomeNotAcquired,
+		slog.Duration("wait", remaining))
+	return sleep(ctx, remaining)
+}
+
+// stealDelay staggers the candidates claiming an expired lease: half of the steal backoff is spread by the
+// candidate's position, a hash of its name, so candidates wake up in a stable order, and the other half is random,
+// so candidates whose positions collide still don't claim it together.
+func (e *Election) stealDelay() time.Duration {
+	h := fnv.New32a()
+	h.Write([]byte(e.candidate))
+	half := e.opts.stealBackoff / 2
+	return spread(half, h.Sum32()) + rand.N(half+1)
+}
+
+// spread scales d by hash/2^32, in 128 bits so long durations don't overflow.
+func spread(d time.Duration, hash uint32) time.Duration {
+	hi, lo := bits.Mul64(uint64(d), uint64(hash))
+	return time.Duration(hi<<32 | lo>>32)
+}
+
+// stopError classifies a database error that stops the election, preferring the context error when the failure was
+// caused by ctx being done.
+func stopError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return fmt.Errorf("%w: %w", ErrNotConnected, err)
+}
+
+// sleep waits for d, returning early with the context error if ctx is done first.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// admit runs the checks a won election must pass before declaring leadership, WithFence and WithValidateLeadership,
+// returning the message to log for the one that failed.
+func (e *Election) admit(ctx context.Context) (string, error) {
+	if e.opts.fence != nil {
+		if err := e.opts.fence(ctx, e.Term()); err != nil {
+			return "fence failed, resigning", err
+		}
+	}
+	if e.opts.validator != nil {
+		if err := e.opts.validator(ctx); err != nil {
+			return "leadership validation failed, resigning", err
+		}
+	}
+	return "", nil
+}
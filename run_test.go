@@ -0,0 +1,42 @@
+package leaderelection
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSpread(t *testing.T) {
+	for _, tc := range []struct {
+		d    time.Duration
+		hash uint32
+		want time.Duration
+	}{
+		{10 * time.Second, 0, 0},
+		{10 * time.Second, 1 << 31, 5 * time.Second},
+		// beyond 2^32ns, where a 64-bit product overflows
+		{30 * time.Second, 1 << 31, 15 * time.Second},
+		{time.Hour, 1 << 30, 15 * time.Minute},
+	} {
+		if got := spread(tc.d, tc.hash); got != tc.want {
+			t.Errorf("spread(%s, %d) = %s, want %s", tc.d, tc.hash, got, tc.want)
+		}
+	}
+	if got := spread(time.Hour, math.MaxUint32); got <= 59*time.Minute || got > time.Hour {
+		t.Errorf("spread(1h, MaxUint32) = %s, want just under 1h", got)
+	}
+}
+
+func TestStealDelayWithinBackoff(t *testing.T) {
+	for _, backoff := range []time.Duration{time.Second, 20 * time.Second, time.Hour} {
+		e, err := NewMemoryElection(nil, "test", "candidate", WithStealBackoff(backoff))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for range 100 {
+			if delay := e.stealDelay(); delay < 0 || delay > backoff {
+				t.Fatalf("stealDelay() = %s with a %s backoff, want it within [0, %s]", delay, backoff, backoff)
+			}
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package leaderelection
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+// expectedColumns are the column types, as reported by information_schema's DATA_TYPE, the election queries are
+// written for. In particular, last_update and hold_until are compared against NOW(), so they must be datetime: a
+// timestamp column converts to and from the session time zone, which shifts leases between sessions using different
+// time zones.
+var expectedColumns = map[string]string{
+	"id":              "bigint",
+	"election_name":   "varchar",
+	"leader_name":     "varchar",
+	"term":            "bigint",
+	"last_update":     "datetime",
+	"hold_until":      "datetime",
+	"original_name":   "text",
+	"correlation_id":  "varchar",
+	"expires_at_unix": "bigint",
+}
+
+// createMissing creates the tables of models that don't exist yet, leaving existing ones untouched, and warns about
+// election table columns that differ from what the election expects. See WithCreateOnlyMigrate.
+func (e *Election) createMissing(ctx context.Context, tables []tableModel) error {
+	for _, table := range tables {
+		migrator := e.tableDB(ctx, table.placeholder).Migrator()
+		if migrator.HasTable(table.model) {
+			continue
+		}
+		err := e.retryMigration(ctx, func() error {
+			if err := migrator.CreateTable(table.model); !alreadyExists(err) {
+				return err
+			}
+			// created concurrently, which is as good
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create db table with error %s", err.Error())
+		}
+	}
+	return e.verifyColumns(ctx)
+}
+
+// preciseColumns are the timestamp columns WithMicrosecondPrecision needs as DATETIME(6).
+var preciseColumns = []string{"last_update", "hold_until"}
+
+// verifyPrecision makes sure the timestamp columns of the election table keep microseconds when the election is
+// timed with microsecond precision: with alter, it changes them to DATETIME(6), otherwise it only warns about them.
+func (e *Election) verifyPrecision(ctx context.Context, alter bool) error {
+	if !e.opts.microseconds {
+		return nil
+	}
+	columns, err := e.tableDB(ctx, "{records}").Migrator().ColumnTypes(&ElectionRecord{})
+	if err != nil {
+		return fmt.Errorf("failed to read the columns of the election table: %w", err)
+	}
+	var modify []string
+	for _, column := range columns {
+		name := strings.ToLower(column.Name())
+		if !slices.Contains(preciseColumns, name) {
+			continue
+		}
+		if columnType, _ := column.ColumnType(); strings.EqualFold(columnType, "datetime(6)") {
+			continue
+		}
+		if !alter {
+			e.logger.Warn("election table column lacks microsecond precision, leaving it unaltered",
+				slog.String("column", name), slog.String("expected_type", "datetime(6)"))
+			continue
+		}
+		modify = append(modify, "MODIFY "+name+" DATETIME(6) NULL")
+	}
+	if len(modify) == 0 {
+		return nil
+	}
+	if err = e.db.WithContext(ctx).Exec(e.sql("ALTER TABLE {records} " + strings.Join(modify, ", "))).Error; err != nil {
+		return fmt.Errorf("failed to change the election table to microsecond precision: %w", err)
+	}
+	e.logger.Info("changed the election table to microsecond precision")
+	return nil
+}
+
+// verifyColumns logs a warning for every column of the election table that is missing or has another type than
+// expectedColumns.
+func (e *Election) verifyColumns(ctx context.Context) error {
+	columns, err := e.tableDB(ctx, "{records}").Migrator().ColumnTypes(&ElectionRecord{})
+	if err != nil {
+		return fmt.Errorf("failed to read the columns of the election table: %w", err)
+	}
+	found := make(map[string]string, len(columns))
+	for _, column := range columns {
+		found[strings.ToLower(column.Name())] = strings.ToLower(column.DatabaseTypeName())
+	}
+	for name, expected := range expectedColumns {
+		switch actual, ok := found[name]; {
+		case !ok:
+			e.logger.Warn("election table lacks a column, leaving it unaltered", slog.String("column", name),
+				slog.String("expected_type", expected))
+		case actual != expected:
+			e.logger.Warn("election table column has an unexpected type, leaving it unaltered",
+				slog.String("column", name), slog.String("type", actual), slog.String("expected_type", expected))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package leaderelection
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+)
+
+// racingSchema is an empty schema that a number of concurrent migrations all find empty at first, so each of them
+// tries to create every table, and all but the first lose the race to it.
+type racingSchema struct {
+	migrations int
+
+	mu      sync.Mutex
+	created map[string]bool
+	asked   map[string]int
+	ready   map[string]chan struct{}
+}
+
+var createTable = regexp.MustCompile("^CREATE TABLE `([^`]+)`")
+
+func (s *racingSchema) handle(query fakeQuery) (*fakeResult, error) {
+	switch {
+	case query.sql == "SELECT DATABASE()":
+		return row([]string{"DATABASE()"}, "test"), nil
+	case strings.Contains(query.sql, "information_schema.tables"):
+		table := fmt.Sprint(query.args[1].Value)
+		return row([]string{"count(*)"}, s.hasTable(table)), nil
+	}
+	if m := createTable.FindStringSubmatch(query.sql); m != nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.created[m[1]] {
+			return nil, &mysqldriver.MySQLError{Number: errTableExists, Message: fmt.Sprintf("Table '%s' already exists", m[1])}
+		}
+		s.created[m[1]] = true
+	}
+	return nil, nil
+}
+
+// hasTable reports whether table exists, but holds the first check of every migration until all of them made it (or
+// some gave up), and then reports the table missing to all of them.
+func (s *racingSchema) hasTable(table string) driver.Value {
+	s.mu.Lock()
+	if s.asked[table] >= s.migrations {
+		defer s.mu.Unlock()
+		if s.created[table] {
+			return int64(1)
+		}
+		return int64(0)
+	}
+	s.asked[table]++
+	ready, ok := s.ready[table]
+	if !ok {
+		ready = make(chan struct{})
+		s.ready[table] = ready
+	}
+	if s.asked[table] == s.migrations {
+		close(ready)
+	}
+	s.mu.Unlock()
+	select {
+	case <-ready:
+	case <-time.After(time.Second):
+	}
+	return int64(0)
+}
+
+// TestConcurrentMigrationsOfEmptySchema starts elections together against an empty schema, as replicas of a new
+// deployment do, and checks they all come up.
+func TestConcurrentMigrationsOfEmptySchema(t *testing.T) {
+	const elections = 8
+	schema := &racingSchema{
+		migrations: elections,
+		created:    make(map[string]bool),
+		asked:      make(map[string]int),
+		ready:      make(map[string]chan struct{}),
+	}
+	db := &fakeDB{handle: schema.handle}
+	errs := make([]error, elections)
+	var wg sync.WaitGroup
+	for i := range elections {
+		e := newFakeElection(t, db, WithHistory(), WithStats())
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = e.attach()
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		t.Fatalf("concurrent migrations failed: %v", err)
+	}
+	for _, table := range []string{"election_records", "election_history", "election_stats"} {
+		if !schema.created[table] {
+			t.Errorf("table %s wasn't created", table)
+		}
+	}
+}
+
+// legacySchema is an election table as created by the earliest versions: without the unique index on election_name,
+// so with duplicate rows, and without the columns added since, such as term.
+type legacySchema struct {
+	mu      sync.Mutex
+	columns map[string]bool
+	indexed bool
+}
+
+var addColumn = regexp.MustCompile("^ALTER TABLE `[^`]+` ADD `([^`]+)`")
+
+func (s *legacySchema) handle(query fakeQuery) (*fakeResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case query.sql == "SELECT DATABASE()":
+		return row([]string{"DATABASE()"}, "test"), nil
+	case strings.Contains(query.sql, "INFORMATION_SCHEMA.columns"):
+		return row([]string{"count(*)"}, boolCount(s.columns[fmt.Sprint(query.args[2].Value)])), nil
+	case strings.Contains(query.sql, "information_schema.statistics"):
+		return row([]string{"count(*)"}, boolCount(s.indexed)), nil
+	case strings.HasPrefix(query.sql, "CREATE UNIQUE INDEX"):
+		s.indexed = true
+		return nil, nil
+	}
+	if m := addColumn.FindStringSubmatch(query.sql); m != nil {
+		s.columns[m[1]] = true
+		return nil, nil
+	}
+	if strings.Contains(query.sql, "term") && !s.columns["term"] {
+		return nil, &mysqldriver.MySQLError{Number: 1054, Message: "Unknown column 'term' in 'field list'"}
+	}
+	switch {
+	case strings.HasPrefix(query.sql, "SELECT election_name, COUNT(*)"):
+		return row([]string{"election_name", "row_count"}, "test", int64(2)), nil
+	case strings.HasPrefix(query.sql, "SELECT id, "):
+		return row([]string{"id", "term"}, int64(2), int64(0)), nil
+	}
+	return nil, nil
+}
+
+func boolCount(ok bool) driver.Value {
+	if ok {
+		return int64(1)
+	}
+	return int64(0)
+}
+
+func TestRepairLegacyTable(t *testing.T) {
+	schema := &legacySchema{columns: map[string]bool{
+		"id": true, "election_name": true, "leader_name": true, "last_update": true,
+	}}
+	db := &fakeDB{handle: schema.handle}
+	// as created WithoutAutoMigrate: the tables are only resolved
+	e := newFakeElection(t, db)
+	report, err := e.Repair(context.Background(), false)
+	if err != nil {
+		t.Fatalf("Repair() = %v", err)
+	}
+	if !slices.Contains(report.ColumnsMissing, "term") || !schema.columns["term"] {
+		t.Errorf("ColumnsMissing = %v, want the term column found missing and added", report.ColumnsMissing)
+	}
+	if report.Duplicates["test"] != 2 || !report.IndexMissing || !schema.indexed {
+		t.Errorf("Repair() = %+v, want the duplicates collapsed and the index created", report)
+	}
+	var deleted bool
+	for _, query := range db.received() {
+		deleted = deleted || strings.HasPrefix(query.sql, "DELETE FROM election_records")
+		if !query.hasDeadline() {
+			t.Errorf("Repair sent a statement without a deadline:\n%s", query.sql)
+		}
+	}
+	if !deleted {
+		t.Error("the duplicate rows weren't collapsed")
+	}
+}
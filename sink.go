@@ -0,0 +1,74 @@
+package leaderelection
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+)
+
+// ElectionStatus is the state of an election as seen by the candidate running it. It marshals to JSON with the
+// field names given in its tags, times as RFC 3339, plus the computed "lease_valid" (see LeaseValid), so it can be
+// served as is from status endpoints.
+type ElectionStatus struct {
+	ElectionName string `json:"election_name"`
+	Candidate    string `json:"candidate"`
+	// Leading reports whether Candidate holds the lease.
+	Leading bool `json:"leading"`
+	// Term is the term held, or the term last held while not leading.
+	Term uint64 `json:"term"`
+	// LeaseExpiry is when the lease lapses unless renewed again, estimated from the last successful renewal; it is
+	// zero, and omitted from JSON, while not leading.
+	LeaseExpiry time.Time `json:"lease_expiry,omitzero"`
+	Time        time.Time `json:"time"`
+}
+
+// LeaseValid reports whether Candidate held an unexpired lease at the time of the status.
+func (s ElectionStatus) LeaseValid() bool {
+	return s.Leading && s.LeaseExpiry.After(s.Time)
+}
+
+func (s ElectionStatus) MarshalJSON() ([]byte, error) {
+	// the alias drops this method, so marshalling it doesn't recurse
+	type status ElectionStatus
+	return json.Marshal(struct {
+		status
+		LeaseValid bool `json:"lease_valid"`
+	}{status(s), s.LeaseValid()})
+}
+
+// StateSink receives the election state RunElection observes, e.g. to mirror the MySQL-elected leader into etcd or
+// Consul for systems that read leadership from there.
+type StateSink interface {
+	// Publish is called on every transition and after every campaign, i.e. every renew interval while leading. Every
+	// candidate publishes its own view, so a mirror should take the leader from the statuses that are Leading. It
+	// runs on the election loop, bounded by the query timeout; errors are logged and don't affect the election.
+	Publish(ctx context.Context, status ElectionStatus) error
+}
+
+func (e *Election) status(leading bool) ElectionStatus {
+	status := ElectionStatus{
+		ElectionName: e.name,
+		Candidate:    e.candidate,
+		Leading:      leading,
+		Term:         e.Term(),
+		Time:         time.Now(),
+	}
+	if renewed := e.renewedAt.Load(); leading && renewed != 0 {
+		status.LeaseExpiry = time.Unix(0, renewed).Add(e.opts.leaseDuration)
+	}
+	return status
+}
+
+// publishStatus hands the current state to the configured sink. It also runs while the election stops, so the final
+// not-leading state still reaches the sink after ctx is done.
+func (e *Election) publishStatus(ctx context.Context, leading bool) {
+	if e.opts.sink == nil {
+		return
+	}
+	ctx, cancel := e.queryContext(context.WithoutCancel(ctx))
+	defer cancel()
+	if err := e.opts.sink.Publish(ctx, e.status(leading)); err != nil {
+		e.logEvent(ctx, slog.LevelWarn, "failed to publish election state", OutcomeError, slog.Any("error", err))
+	}
+}
@@ -0,0 +1,169 @@
+package leaderelection
+
+import (
+	"fmt"
+)
+
+// SQLBuilder produces the statements elections use to acquire, renew, resign and read leases, for MySQL variants that
+// need different SQL (hints, functions, optimizer directives). Each statement is built for the election table and the
+// lease parameters, and executed with the placeholder arguments its method documents, in order. Implementations
+// can embed MySQLBuilder and override only the statements they need to change. Any hints set with WithQueryHints are
+// still prepended.
+type SQLBuilder interface {
+	// Acquire inserts the election row, or takes over a lease that has expired, starting a new term, or renews the
+	// lease held by the candidate. It must only affect a row when the candidate holds the lease afterwards, and clear
+	// the correlation_id of a lease it takes over. Arguments: election name, candidate, original election name.
+	Acquire(table string, lease LeaseParams) string
+	// Renew extends the lease if the candidate holds it. Arguments: election name, candidate.
+	Renew(table string, lease LeaseParams) string
+	// RenewTerm extends the lease if the candidate holds it under the given term. Arguments: election name,
+	// candidate, term.
+	RenewTerm(table string, lease LeaseParams) string
+	// Resign expires the lease if the candidate holds it, keeping the row. Arguments: election name, candidate.
+	Resign(table string, lease LeaseParams) string
+	// IsLeader selects the term held by the candidate, if it holds a valid lease. Arguments: election name, candidate.
+	IsLeader(table string, lease LeaseParams) string
+	// Leader selects the name of the candidate holding a valid lease, if any. Arguments: election name.
+	Leader(table string, lease LeaseParams) string
+}
+
+// LeaseParams are the lease settings of the election a statement is built for.
+type LeaseParams struct {
+	// Seconds is the lease duration.
+	Seconds int64
+	// MinHoldSeconds is how long a newly acquired lease can't be taken over, even if it isn't renewed (see
+	// WithMinHoldDuration); Acquire records the end of that period in hold_until.
+	MinHoldSeconds int64
+	// SkewSeconds is how much longer than the lease a challenger waits before taking over a lease that wasn't renewed
+	// (see WithClockSkewTolerance).
+	SkewSeconds int64
+	// Microseconds is set for elections timed with microsecond precision (see WithMicrosecondPrecision): the
+	// timestamp columns are then DATETIME(6), statements compare them with NOW(6), and LeaseMicros, MinHoldMicros and
+	// SkewMicros give the durations above exactly, where the seconds are rounded.
+	Microseconds                           bool
+	LeaseMicros, MinHoldMicros, SkewMicros int64
+	// Epoch is set for elections tracking leases by an integer epoch (see WithEpochLease): the lease is held while
+	// expires_at_unix, set to UNIX_TIMESTAMP() plus the lease on every acquisition and renewal, hasn't passed, and
+	// last_update is only kept for information.
+	Epoch bool
+}
+
+// MySQLBuilder is the default SQLBuilder, for MySQL 5.7 and later.
+type MySQLBuilder struct{}
+
+var _ SQLBuilder = MySQLBuilder{}
+
+func (MySQLBuilder) Acquire(table string, lease LeaseParams) string {
+	// a lease can be taken over once it expired, including the skew tolerance, and the minimum hold period of its
+	// acquisition is over. The leader's own lease lapses after the lease alone: it takes it over under a new term
+	// rather than renewing it within the skew tolerance. The assignments are evaluated in order, each seeing the
+	// columns assigned before it, so everything deciding on a takeover is assigned before last_update, and hold_until
+	// after the columns that test it.
+	now := lease.now()
+	isLeader := `leader_name = CAST(VALUES(leader_name) AS BINARY)`
+	expired := `(NOT (` + lease.heldFor(lease.Seconds+lease.SkewSeconds, lease.LeaseMicros+lease.SkewMicros) +
+		`) or (` + isLeader + ` and NOT (` + lease.held() + `))) and (hold_until IS NULL or hold_until <= ` + now + `)`
+	holdUntil := now + ` + ` + lease.interval(lease.MinHoldSeconds, lease.MinHoldMicros)
+	columns, values, expiry := ``, ``, ``
+	if lease.Epoch {
+		columns, values = `, expires_at_unix`, `, `+lease.epochExpiry()
+		expiry = `expires_at_unix = IF(` + isLeader + `, ` + lease.epochExpiry() + `, expires_at_unix),
+			`
+	}
+	return `INSERT INTO ` + table + ` (election_name, leader_name, term, last_update, hold_until, original_name` +
+		columns + `)
+			VALUES (?, ?, 1, ` + now + `, ` + holdUntil + `, ?` + values + `)
+			ON DUPLICATE KEY UPDATE
+			term = IF(` + expired + `, term + 1, term),
+			leader_name = IF(` + expired + `, VALUES(leader_name), leader_name),
+			correlation_id = IF(` + expired + `, NULL, correlation_id),
+			hold_until = IF(` + expired + `, ` + holdUntil + `, hold_until),
+			` + expiry + `last_update = IF(` + isLeader + `, ` + now + `, last_update)`
+}
+
+func (MySQLBuilder) Renew(table string, lease LeaseParams) string {
+	return `UPDATE ` + table + ` SET ` + lease.renewal() + ` WHERE election_name=? and ` + isCandidate + ` and ` +
+		lease.held()
+}
+
+func (MySQLBuilder) RenewTerm(table string, lease LeaseParams) string {
+	return `UPDATE ` + table + ` SET ` + lease.renewal() + ` WHERE election_name=? and ` + isCandidate +
+		` and term=? and ` + lease.held()
+}
+
+func (MySQLBuilder) Resign(table string, lease LeaseParams) string {
+	return `UPDATE ` + table + ` SET ` + lease.lapse() + ` WHERE election_name=? and ` + isCandidate + ` and ` +
+		lease.held()
+}
+
+func (MySQLBuilder) IsLeader(table string, lease LeaseParams) string {
+	return `SELECT term FROM ` + table + ` where election_name=? and ` + isCandidate + ` and ` + lease.held()
+}
+
+func (MySQLBuilder) Leader(table string, lease LeaseParams) string {
+	return `SELECT leader_name FROM ` + table + ` where election_name=? and ` + lease.held()
+}
+
+// now is the server clock leases are timed with.
+func (p LeaseParams) now() string {
+	if p.Microseconds {
+		return `NOW(6)`
+	}
+	return `NOW()`
+}
+
+// interval is an interval of seconds, or of micros with microsecond precision.
+func (p LeaseParams) interval(seconds, micros int64) string {
+	if p.Microseconds {
+		return fmt.Sprintf(`INTERVAL %d MICROSECOND`, micros)
+	}
+	return fmt.Sprintf(`INTERVAL %d SECOND`, seconds)
+}
+
+// held is the condition for the lease being held.
+func (p LeaseParams) held() string {
+	return p.heldFor(p.Seconds, p.LeaseMicros)
+}
+
+// heldFor is the condition for the lease having been renewed within the given interval, see interval. With Epoch,
+// the renewal is when expires_at_unix was set, the lease before it.
+func (p LeaseParams) heldFor(seconds, micros int64) string {
+	if p.Epoch {
+		return fmt.Sprintf(`expires_at_unix + %d >= `+epochNow, seconds-p.Seconds)
+	}
+	return `last_update >= ` + p.now() + ` - ` + p.interval(seconds, micros)
+}
+
+// renewal is the assignment renewing the lease.
+func (p LeaseParams) renewal() string {
+	if p.Epoch {
+		return `last_update = ` + p.now() + `, expires_at_unix = ` + p.epochExpiry()
+	}
+	return `last_update = ` + p.now()
+}
+
+// epochExpiry is the expires_at_unix of a lease acquired or renewed now, see Epoch.
+func (p LeaseParams) epochExpiry() string {
+	return fmt.Sprintf(epochNow+` + %d`, p.Seconds)
+}
+
+// lapse is the assignment back-dating the lease past the point any challenger considers it expired.
+func (p LeaseParams) lapse() string {
+	expired := p.interval(p.Seconds+p.SkewSeconds+1, p.LeaseMicros+p.SkewMicros+1)
+	set := `last_update = ` + p.now() + ` - ` + expired
+	if p.Epoch {
+		set += fmt.Sprintf(`, expires_at_unix = `+epochNow+` - %d`, p.SkewSeconds+1)
+	}
+	return set
+}
+
+// epochNow is the server clock epoch leases are timed with, signed so differences with it can be negative.
+const epochNow = `CAST(UNIX_TIMESTAMP() AS SIGNED)`
+
+// untilExpiry is the number of microseconds until the lease, extended by the given interval, expires.
+func (p LeaseParams) untilExpiry(seconds, micros int64) string {
+	if p.Epoch {
+		return fmt.Sprintf(`(expires_at_unix + %d - `+epochNow+`) * 1000000`, seconds)
+	}
+	return `TIMESTAMPDIFF(MICROSECOND, ` + p.now() + `, last_update + ` + p.interval(p.Seconds+seconds, p.LeaseMicros+micros) + `)`
+}
@@ -0,0 +1,96 @@
+package leaderelection
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+// leaseVariants are the lease timings the builder has to produce consistent statements for.
+var leaseVariants = map[string][]Option{
+	"seconds":      nil,
+	"microseconds": {WithMicrosecondPrecision()},
+	"epoch":        {WithEpochLease()},
+	"skew":         {WithClockSkewTolerance(5 * time.Second), WithMinHoldDuration(10 * time.Second)},
+}
+
+func leaseParamsFor(t *testing.T, opts ...Option) LeaseParams {
+	t.Helper()
+	e, err := NewMemoryElection(nil, "test", "candidate", opts...)
+	if err != nil {
+		t.Fatalf("NewMemoryElection: %v", err)
+	}
+	return e.leaseParams()
+}
+
+func TestBuilderSharesLeaseArithmetic(t *testing.T) {
+	var builder MySQLBuilder
+	for name, opts := range leaseVariants {
+		t.Run(name, func(t *testing.T) {
+			lease := leaseParamsFor(t, opts...)
+			held := lease.held()
+			for method, sql := range map[string]string{
+				"Renew":     builder.Renew("election_records", lease),
+				"RenewTerm": builder.RenewTerm("election_records", lease),
+				"Resign":    builder.Resign("election_records", lease),
+				"IsLeader":  builder.IsLeader("election_records", lease),
+				"Leader":    builder.Leader("election_records", lease),
+			} {
+				if !strings.Contains(sql, held) {
+					t.Errorf("%s doesn't test the lease with %q:\n%s", method, held, sql)
+				}
+			}
+			// a campaign waits out the skew tolerance on top of the same lease
+			expired := lease.heldFor(lease.Seconds+lease.SkewSeconds, lease.LeaseMicros+lease.SkewMicros)
+			if sql := builder.Acquire("election_records", lease); !strings.Contains(sql, expired) {
+				t.Errorf("Acquire doesn't test the lease with %q:\n%s", expired, sql)
+			}
+			// while the leader takes over its own lapsed lease without it
+			lapsed := "leader_name = CAST(VALUES(leader_name) AS BINARY) and NOT (" + held + ")"
+			if sql := builder.Acquire("election_records", lease); !strings.Contains(sql, lapsed) {
+				t.Errorf("Acquire doesn't take over the leader's lapsed lease with %q:\n%s", lapsed, sql)
+			}
+		})
+	}
+}
+
+// TestAcquireConvergesOnElectionName checks what makes concurrent first campaigns converge: the unique index on
+// election_name, which a plain upsert (not INSERT IGNORE) turns into an update of the row the first insert created.
+func TestAcquireConvergesOnElectionName(t *testing.T) {
+	parsed, err := schema.Parse(&ElectionRecord{}, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		t.Fatalf("schema.Parse: %v", err)
+	}
+	index, ok := parsed.ParseIndexes()["uidx_election_name"]
+	if !ok || index.Class != "UNIQUE" || len(index.Fields) != 1 || index.Fields[0].DBName != "election_name" {
+		t.Fatalf("uidx_election_name = %+v, want a unique index on election_name", index)
+	}
+	sql := MySQLBuilder{}.Acquire("election_records", leaseParamsFor(t))
+	if !strings.HasPrefix(sql, "INSERT INTO ") || !strings.Contains(sql, "ON DUPLICATE KEY UPDATE") {
+		t.Fatalf("Acquire isn't an upsert on the unique index:\n%s", sql)
+	}
+}
+
+// TestBuilderComparesCandidatesAsBinary checks that candidate names are compared as binary strings, so names that
+// differ only by case aren't conflated under a case-insensitive collation.
+func TestBuilderComparesCandidatesAsBinary(t *testing.T) {
+	var builder MySQLBuilder
+	lease := leaseParamsFor(t)
+	for method, sql := range map[string]string{
+		"Renew":     builder.Renew("election_records", lease),
+		"RenewTerm": builder.RenewTerm("election_records", lease),
+		"Resign":    builder.Resign("election_records", lease),
+		"IsLeader":  builder.IsLeader("election_records", lease),
+	} {
+		if !strings.Contains(sql, "leader_name = CAST(? AS BINARY)") {
+			t.Errorf("%s doesn't compare the candidate as binary:\n%s", method, sql)
+		}
+	}
+	if sql := builder.Acquire("election_records", lease); !strings.Contains(sql,
+		"leader_name = CAST(VALUES(leader_name) AS BINARY)") {
+		t.Errorf("Acquire doesn't compare the candidate as binary:\n%s", sql)
+	}
+}
@@ -0,0 +1,73 @@
+package leaderelection
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// StatsEntry is the durable tally of an election's takeovers, kept by candidates running WithStats.
+type StatsEntry struct {
+	ID           uint   `gorm:"primaryKey"`
+	ElectionName string `gorm:"uniqueIndex:uidx_election_stats"`
+	Acquisitions uint64
+	LastLeader   string
+	LastTerm     uint64
+	LastAcquired time.Time
+}
+
+func (StatsEntry) TableName() string {
+	return "election_stats"
+}
+
+// ElectionDBStats is the durable view of how often an election's leadership changed, as recorded in the
+// election_stats table. Unlike the in-process counters of WriteMetrics it survives restarts and spans every candidate
+// recording it.
+type ElectionDBStats struct {
+	// Acquisitions counts the takeovers recorded, each starting a new term.
+	Acquisitions uint64 `json:"acquisitions"`
+	// LastLeader, LastTerm and LastTransition describe the most recent takeover recorded.
+	LastLeader     string    `json:"last_leader"`
+	LastTerm       uint64    `json:"last_term"`
+	LastTransition time.Time `json:"last_transition,omitzero"`
+}
+
+// ElectionStats returns the takeovers recorded for the election in the election_stats table, or zero stats if none
+// were. Takeovers are only recorded by candidates running WithStats. See DBStats for the connection pool's statistics.
+func (e *Election) ElectionStats(ctx context.Context) (ElectionDBStats, error) {
+	ctx = orBackground(ctx)
+	if err := e.checkOpen(); err != nil {
+		return ElectionDBStats{}, err
+	}
+	if e.memory != nil {
+		return ElectionDBStats{}, errMemoryUnsupported("ElectionStats")
+	}
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	var entry StatsEntry
+	sql := `SELECT acquisitions, last_leader, last_term, last_acquired FROM {stats} WHERE election_name=?`
+	if err := e.conn(ctx).Raw(e.readSQL(sql), e.storedName).Scan(&entry).Error; err != nil {
+		return ElectionDBStats{}, ctxError(ctx, err)
+	}
+	return ElectionDBStats{
+		Acquisitions:   entry.Acquisitions,
+		LastLeader:     entry.LastLeader,
+		LastTerm:       entry.LastTerm,
+		LastTransition: entry.LastAcquired,
+	}, nil
+}
+
+// recordStats counts the term this candidate just won in the stats table. A term is counted once, however often it is
+// recorded, so renewals of a term already counted leave the stats untouched. The assignments are evaluated in order,
+// so last_term is assigned after the columns comparing against it.
+func (e *Election) recordStats(db *gorm.DB) error {
+	sql := `INSERT INTO {stats} (election_name, acquisitions, last_leader, last_term, last_acquired)
+			SELECT election_name, 1, leader_name, term, last_update FROM {records} WHERE election_name=? and ` + isCandidate + `
+			ON DUPLICATE KEY UPDATE
+			acquisitions = IF(VALUES(last_term) > last_term, acquisitions + 1, acquisitions),
+			last_leader = IF(VALUES(last_term) > last_term, VALUES(last_leader), last_leader),
+			last_acquired = IF(VALUES(last_term) > last_term, VALUES(last_acquired), last_acquired),
+			last_term = GREATEST(VALUES(last_term), last_term)`
+	return db.Exec(e.writeSQL(sql), e.storedName, e.candidate).Error
+}
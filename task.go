@@ -0,0 +1,141 @@
+package leaderelection
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// RunLeaderTask runs fn every interval, but only while e's Run loop holds leadership: it starts as soon as leadership
+// is won, pauses when it is lost, and resumes when it is won again. The context of a run is cancelled as soon as
+// leadership is lost, so an in-flight run stops. Runs never overlap; errors are logged and the task carries on.
+// RunLeaderTask returns the context error once ctx is done. The election has to be run separately.
+func (e *Election) RunLeaderTask(ctx context.Context, interval time.Duration, fn func(ctx context.Context) error) error {
+	ctx = orBackground(ctx)
+	if err := e.checkOpen(); err != nil {
+		return err
+	}
+	var work *leaderWork
+	pause := func() {
+		if work != nil {
+			work.cancel()
+			<-work.done
+			work = nil
+		}
+	}
+	defer pause()
+	for event := range e.Subscribe(ctx) {
+		switch {
+		case event.Leading && work == nil:
+			work = startLeaderWork(ctx, e.opts.executor, func(ctx context.Context) {
+				e.runTask(ctx, interval, fn)
+			})
+		case !event.Leading:
+			pause()
+		}
+	}
+	return ctx.Err()
+}
+
+// DoIfLeader runs fn only if this candidate holds a valid lease, as verified against the database, and returns
+// ErrNotLeader without running it otherwise. While fn runs, the lease is checked again every renew interval, and the
+// context passed to fn is cancelled, with ErrLeaseLost as its cause, as soon as a check fails to confirm it, so fn
+// stops acting as leader. The lease itself has to be kept by a running election. DoIfLeader returns fn's error.
+func (e *Election) DoIfLeader(ctx context.Context, fn func(ctx context.Context) error) error {
+	ctx = orBackground(ctx)
+	leading, err := e.IsLeader(ctx)
+	if err != nil {
+		return err
+	}
+	if !leading {
+		return ErrNotLeader
+	}
+	guarded, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	go func() {
+		for sleep(guarded, e.opts.renewInterval) == nil {
+			if leading, err := e.IsLeader(guarded); !leading || err != nil {
+				if guarded.Err() == nil {
+					e.logEvent(ctx, slog.LevelWarn, "leadership no longer confirmed, cancelling guarded work", OutcomeLost,
+						slog.Any("error", err))
+				}
+				cancel(ErrLeaseLost)
+				return
+			}
+		}
+	}()
+	return fn(guarded)
+}
+
+// WithLeadership runs fn exactly while this candidate leads, without a separate election loop: it campaigns, returning
+// ErrNotLeader without running fn if another candidate holds the lease, then renews the lease every renew interval
+// for as long as fn runs, and stops renewing once fn returns. The context passed to fn is cancelled, with ErrLeaseLost
+// as its cause, as soon as a renewal reports the lease lost, or once the lease would expire while renewals fail: a
+// query timeout before a lease duration has passed since the start of the last successful campaign or renewal.
+// WithLeadership then returns ErrLeaseLost. Otherwise it returns fn's error. The lease is kept when fn returns, so a
+// running election or the next WithLeadership can carry on from it; Resign to hand it over.
+func (e *Election) WithLeadership(ctx context.Context, fn func(ctx context.Context) error) error {
+	ctx = orBackground(ctx)
+	campaigned := time.Now()
+	won, err := e.Campaign(ctx)
+	if err != nil {
+		return err
+	}
+	if !won {
+		return ErrNotLeader
+	}
+	guarded, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	// the lease is only known to last from the start of the statement that extended it, and a renewal in flight may
+	// take up to the query timeout to tell whether it did
+	valid := e.opts.leaseDuration - e.opts.queryTimeout
+	expiry := time.AfterFunc(time.Until(campaigned.Add(valid)), func() {
+		if guarded.Err() == nil {
+			e.logEvent(ctx, slog.LevelWarn, "lease expiring while renewals failed, cancelling leader-only work", OutcomeLost)
+		}
+		cancel(ErrLeaseLost)
+	})
+	defer expiry.Stop()
+	renewing := make(chan struct{})
+	go func() {
+		defer close(renewing)
+		for sleep(guarded, e.opts.renewInterval) == nil {
+			started := time.Now()
+			held, err := e.Renew(guarded)
+			switch {
+			case guarded.Err() != nil:
+				return
+			case err == nil && held:
+				expiry.Reset(time.Until(started.Add(valid)))
+				continue
+			case err == nil || errors.Is(err, ErrResigned):
+				e.logEvent(ctx, slog.LevelWarn, "lease lost, cancelling leader-only work", OutcomeLost, slog.Any("error", err))
+			default:
+				e.logEvent(ctx, slog.LevelWarn, "failed to renew the lease, retrying", OutcomeError, slog.Any("error", err))
+				continue
+			}
+			cancel(ErrLeaseLost)
+			return
+		}
+	}()
+	err = fn(guarded)
+	lost := errors.Is(context.Cause(guarded), ErrLeaseLost)
+	cancel(nil)
+	<-renewing
+	if lost {
+		return ErrLeaseLost
+	}
+	return err
+}
+
+func (e *Election) runTask(ctx context.Context, interval time.Duration, fn func(ctx context.Context) error) {
+	for {
+		if err := fn(ctx); err != nil && ctx.Err() == nil {
+			e.logEvent(ctx, slog.LevelWarn, "leader task failed", OutcomeError, slog.Any("error", err))
+		}
+		if sleep(ctx, interval) != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package leaderelection
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestWithLeadershipStopsBeforeLeaseExpires checks that while renewals fail, fn is cancelled before the lease won by
+// the campaign expires, so it can't overlap with a candidate taking over.
+func TestWithLeadershipStopsBeforeLeaseExpires(t *testing.T) {
+	const lease = 300 * time.Millisecond
+	holder := leaseHolder("candidate")
+	e := newFakeElection(t, &fakeDB{handle: func(query fakeQuery) (*fakeResult, error) {
+		if strings.HasPrefix(query.sql, "UPDATE election_records SET") {
+			return nil, errKilledConn
+		}
+		return holder(query)
+	}}, WithMicrosecondPrecision(), WithLeaseDuration(lease), WithRenewInterval(100*time.Millisecond))
+	started := time.Now()
+	var stopped time.Duration
+	var cause error
+	err := e.WithLeadership(context.Background(), func(ctx context.Context) error {
+		<-ctx.Done()
+		stopped, cause = time.Since(started), context.Cause(ctx)
+		return nil
+	})
+	if !errors.Is(err, ErrLeaseLost) || !errors.Is(cause, ErrLeaseLost) {
+		t.Fatalf("WithLeadership() = %v with cause %v, want ErrLeaseLost", err, cause)
+	}
+	if stopped >= lease {
+		t.Fatalf("fn was cancelled %s after the campaign started, want before the %s lease expired", stopped, lease)
+	}
+}
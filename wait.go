@@ -0,0 +1,117 @@
+package leaderelection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// WaitForLeader blocks until some candidate holds a valid lease on the election and returns its name, or returns the
+// context error once ctx is done. The election is polled with exponential backoff, capped at the renew interval.
+// Cancelling ctx interrupts both the wait between polls and a lookup in flight, so it returns promptly rather than
+// after a full backoff.
+func (e *Election) WaitForLeader(ctx context.Context) (string, error) {
+	ctx = orBackground(ctx)
+	backoff := 500 * time.Millisecond
+	for {
+		leader, err := e.GetLeader(ctx)
+		if err == nil {
+			return leader, nil
+		}
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		if errors.Is(err, ErrElectionClosed) {
+			return "", err
+		}
+		if !errors.Is(err, ErrNoLeader) {
+			e.logEvent(ctx, slog.LevelWarn, "failed to look up the leader, will retry", OutcomeError, slog.Any("error", err))
+		}
+		if err = sleep(ctx, backoff); err != nil {
+			return "", err
+		}
+		backoff = min(2*backoff, e.opts.renewInterval)
+	}
+}
+
+// WaitForLeadership campaigns until this candidate wins the election, returning nil once it holds the lease, or
+// returns the context error once ctx is done. Campaigns are repeated every renew interval; transient errors (see
+// WithRetryClassifier) are retried, and any other error is returned. A campaign in flight runs under ctx, so
+// cancelling it stops the campaign as well as the wait. The lease isn't renewed afterwards: the caller must Renew it
+// within the lease, or use Run instead.
+func (e *Election) WaitForLeadership(ctx context.Context) error {
+	ctx = orBackground(ctx)
+	for {
+		won, err := e.Campaign(ctx)
+		if err == nil && won {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			if !e.opts.isRetryable(err) {
+				return err
+			}
+			e.logEvent(ctx, slog.LevelWarn, "campaign failed, will retry", OutcomeError, slog.Any("error", err))
+		}
+		if err = sleep(ctx, e.opts.renewInterval); err != nil {
+			return err
+		}
+	}
+}
+
+// WaitReady blocks until the election database is reachable and the election table can be queried, so startup can
+// check connectivity and credentials before serving, independently of who becomes leader. Transient errors (see
+// WithRetryClassifier) are retried with exponential backoff, capped at the renew interval; it returns any other error
+// wrapped with ErrNotConnected, or the context error once ctx is done.
+func (e *Election) WaitReady(ctx context.Context) error {
+	ctx = orBackground(ctx)
+	if err := e.checkOpen(); err != nil {
+		return err
+	}
+	if e.memory != nil {
+		return nil
+	}
+	backoff := 500 * time.Millisecond
+	for {
+		err := e.ready(ctx)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !e.opts.isRetryable(err) {
+			return fmt.Errorf("%w: %w", ErrNotConnected, err)
+		}
+		e.logEvent(ctx, slog.LevelWarn, "election database not ready, will retry", OutcomeError, slog.Any("error", err))
+		if err = sleep(ctx, backoff); err != nil {
+			return err
+		}
+		backoff = min(2*backoff, e.opts.renewInterval)
+	}
+}
+
+func (e *Election) ready(ctx context.Context) error {
+	ctx, cancel := e.queryContext(ctx)
+	defer cancel()
+	sqlDB, err := e.db.DB()
+	if err != nil {
+		return err
+	}
+	if err = sqlDB.PingContext(ctx); err != nil {
+		return err
+	}
+	var found int
+	err = e.conn(ctx).Raw(e.readSQL(`SELECT 1 FROM {records} LIMIT 1`)).Scan(&found).Error
+	if err != nil {
+		var retry bool
+		if retry, err = e.recoverMissingTable(ctx, err); retry {
+			return e.conn(ctx).Raw(e.readSQL(`SELECT 1 FROM {records} LIMIT 1`)).Scan(&found).Error
+		}
+	}
+	return err
+}
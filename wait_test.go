@@ -0,0 +1,53 @@
+package leaderelection
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWaitForLeadershipWinsOnceFree(t *testing.T) {
+	registry := NewMemoryRegistry()
+	a := newMemoryCandidate(t, registry, "a")
+	b := newMemoryCandidate(t, registry, "b")
+	if won, err := a.Campaign(context.Background()); err != nil || !won {
+		t.Fatalf("a.Campaign() = %v, %v, want true", won, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	// a's lease expires without being renewed
+	if err := b.WaitForLeadership(ctx); err != nil {
+		t.Fatalf("b.WaitForLeadership() = %v, want nil once a's lease expired", err)
+	}
+	if isLeader, err := b.IsLeader(ctx); err != nil || !isLeader {
+		t.Fatalf("b.IsLeader() = %v, %v, want true", isLeader, err)
+	}
+}
+
+func TestWaitForLeadershipCancelledMidWait(t *testing.T) {
+	const renewInterval = time.Second
+	registry := NewMemoryRegistry()
+	opts := []Option{WithLeaseDuration(time.Minute), WithRenewInterval(renewInterval)}
+	a, err := NewMemoryElection(registry, "test", "a", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewMemoryElection(registry, "test", "b", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if won, err := a.Campaign(context.Background()); err != nil || !won {
+		t.Fatalf("a.Campaign() = %v, %v, want true", won, err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	started := time.Now()
+	err = b.WaitForLeadership(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("b.WaitForLeadership() = %v, want context.Canceled", err)
+	}
+	if took := time.Since(started); took >= renewInterval {
+		t.Fatalf("b.WaitForLeadership() returned after %s, want it within the %s poll interval", took, renewInterval)
+	}
+}